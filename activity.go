@@ -1,6 +1,7 @@
 package getstream
 
 import (
+	"bytes"
 	"encoding/json"
 	"regexp"
 	"strings"
@@ -11,21 +12,74 @@ import (
 // Use it to post activities to Feeds
 // It is also the response from Fetch and List Requests
 type Activity struct {
-	ID        string
-	Actor     string
-	Verb      string
-	Object    string
-	Target    string
-	Origin    FeedID
+	ID     string
+	Actor  string
+	Verb   string
+	Object string
+	Target string
+	Origin FeedID
+	// TimeStamp is sent and read in UTC with microsecond precision, the current time is sent when it is nil
 	TimeStamp *time.Time
 
 	ForeignID string
 	Data      *json.RawMessage
-	MetaData  map[string]string
+
+	// MetaData holds the custom fields of the activity which are strings
+	MetaData map[string]string
+	// Extra holds the custom fields of the activity which are not strings, numbers are decoded as json.Number
+	// so they keep their precision
+	// When a field is set in both MetaData and Extra, the value of Extra is sent
+	Extra map[string]interface{}
+
+	// ServerTime leaves the TimeStamp out of the payload, so the API assigns the time
+	ServerTime bool
 
 	To []Feed
 }
 
+// activityTimeLayout is the layout of the activity times sent to the API
+const activityTimeLayout = "2006-01-02T15:04:05.000000"
+
+// activityTimeLayouts are the layouts of the activity times read from the API, times without a zone are in UTC
+var activityTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+}
+
+// formatActivityTime formats an activity time for the API, in UTC
+func formatActivityTime(t time.Time) string {
+	return t.UTC().Format(activityTimeLayout)
+}
+
+// parseActivityTime reads an activity time sent by the API, the time returned is in UTC
+func parseActivityTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range activityTimeLayouts {
+		var t time.Time
+		t, err = time.Parse(layout, value)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// activityFields are the keys of the activity payload mapped to the fields of Activity, every other key is a custom field
+var activityFields = map[string]bool{
+	"id":         true,
+	"actor":      true,
+	"verb":       true,
+	"object":     true,
+	"target":     true,
+	"origin":     true,
+	"time":       true,
+	"foreign_id": true,
+	"data":       true,
+	"to":         true,
+}
+
 // MarshalJSON is the custom marshal function for Activities
 // It will be used by json.Marshal()
 func (a Activity) MarshalJSON() ([]byte, error) {
@@ -35,6 +89,9 @@ func (a Activity) MarshalJSON() ([]byte, error) {
 	for key, value := range a.MetaData {
 		payload[key] = value
 	}
+	for key, value := range a.Extra {
+		payload[key] = value
+	}
 
 	payload["actor"] = a.Actor
 	payload["verb"] = a.Verb
@@ -56,10 +113,10 @@ func (a Activity) MarshalJSON() ([]byte, error) {
 		payload["foreign_id"] = a.ForeignID
 	}
 
-	if a.TimeStamp == nil {
-		payload["time"] = time.Now().Format("2006-01-02T15:04:05.999999")
-	} else {
-		payload["time"] = a.TimeStamp.Format("2006-01-02T15:04:05.999999")
+	if a.TimeStamp != nil {
+		payload["time"] = formatActivityTime(*a.TimeStamp)
+	} else if !a.ServerTime {
+		payload["time"] = formatActivityTime(time.Now())
 	}
 
 	var tos []string
@@ -85,6 +142,7 @@ func (a *Activity) UnmarshalJSON(b []byte) (err error) {
 
 	rawPayload := make(map[string]*json.RawMessage)
 	metadata := make(map[string]string)
+	extra := make(map[string]interface{})
 
 	err = json.Unmarshal(b, &rawPayload)
 	if err != nil {
@@ -95,6 +153,9 @@ func (a *Activity) UnmarshalJSON(b []byte) (err error) {
 		lowerKey := strings.ToLower(key)
 
 		if value == nil {
+			if !activityFields[lowerKey] {
+				extra[key] = nil
+			}
 			continue
 		}
 
@@ -132,7 +193,7 @@ func (a *Activity) UnmarshalJSON(b []byte) (err error) {
 			if err != nil {
 				continue
 			}
-			timeStamp, err := time.Parse("2006-01-02T15:04:05.999999", strValue)
+			timeStamp, err := parseActivityTime(strValue)
 			if err != nil {
 				continue
 			}
@@ -199,13 +260,24 @@ func (a *Activity) UnmarshalJSON(b []byte) (err error) {
 				}
 			}
 		} else {
-			var strValue string
-			json.Unmarshal(*value, &strValue)
-			metadata[key] = strValue
+			decoder := json.NewDecoder(bytes.NewReader(*value))
+			decoder.UseNumber()
+
+			var extraValue interface{}
+			if err := decoder.Decode(&extraValue); err != nil {
+				return err
+			}
+			// strings are only kept in MetaData, so changing or deleting them there is what gets sent back
+			if strValue, ok := extraValue.(string); ok {
+				metadata[key] = strValue
+			} else {
+				extra[key] = extraValue
+			}
 		}
 	}
 
 	a.MetaData = metadata
+	a.Extra = extra
 	return nil
 
 }
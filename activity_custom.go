@@ -0,0 +1,148 @@
+package getstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// MarshalActivity encodes v, a struct embedding Activity or a pointer to one, as an activity
+// The embedded Activity is encoded as usual, the other exported fields of v are encoded as custom fields
+// following their json struct tags; v must not embed other types:
+//
+//	type Post struct {
+//		getstream.Activity
+//		Popularity int    `json:"popularity"`
+//		Title      string `json:"title,omitempty"`
+//	}
+//
+//	payload, err := getstream.MarshalActivity(&Post{Activity: activity, Popularity: 42})
+//
+// The fields of v take precedence over the MetaData and Extra of the Activity.
+func MarshalActivity(v interface{}) ([]byte, error) {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() || (value.Kind() == reflect.Ptr && value.IsNil()) {
+		return nil, errors.New("MarshalActivity needs a struct embedding Activity or a non nil pointer to one")
+	}
+	value = reflect.Indirect(value)
+	layout, err := activityLayoutOf(value.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	custom, err := json.Marshal(layout.custom(value).Interface())
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(custom))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	activity := value.Field(layout.activityIndex).Interface().(Activity)
+	extra := make(map[string]interface{}, len(activity.Extra)+len(fields))
+	for key, fieldValue := range activity.Extra {
+		extra[key] = fieldValue
+	}
+	for key, fieldValue := range fields {
+		extra[key] = fieldValue
+	}
+	activity.Extra = extra
+
+	return json.Marshal(activity)
+}
+
+// UnmarshalActivity decodes an activity into v, a pointer to a struct embedding Activity
+// The embedded Activity is decoded as usual, the other exported fields of v are decoded from the custom fields
+// following their json struct tags; the custom fields are kept in the MetaData or Extra of the Activity as well.
+func UnmarshalActivity(data []byte, v interface{}) error {
+	pointer := reflect.ValueOf(v)
+	if pointer.Kind() != reflect.Ptr || pointer.IsNil() {
+		return errors.New("UnmarshalActivity needs a non nil pointer to a struct embedding Activity")
+	}
+	value := pointer.Elem()
+	layout, err := activityLayoutOf(value.Type())
+	if err != nil {
+		return err
+	}
+
+	activity := value.Field(layout.activityIndex).Addr().Interface().(*Activity)
+	if err := json.Unmarshal(data, activity); err != nil {
+		return err
+	}
+
+	custom := reflect.New(layout.customType)
+	if err := json.Unmarshal(data, custom.Interface()); err != nil {
+		return err
+	}
+	for i, index := range layout.customIndexes {
+		value.Field(index).Set(custom.Elem().Field(i))
+	}
+	return nil
+}
+
+// activityLayout describes a struct embedding Activity
+type activityLayout struct {
+	activityIndex int
+	// customType is a struct type with the other exported fields of the struct and their tags,
+	// it has no MarshalJSON method so the json package encodes its fields
+	customType    reflect.Type
+	customIndexes []int
+}
+
+// custom copies the fields of value which are not the Activity into a customType value
+func (l *activityLayout) custom(value reflect.Value) reflect.Value {
+	custom := reflect.New(l.customType).Elem()
+	for i, index := range l.customIndexes {
+		custom.Field(i).Set(value.Field(index))
+	}
+	return custom
+}
+
+var (
+	activityType     = reflect.TypeOf(Activity{})
+	activityLayouts  = make(map[reflect.Type]*activityLayout)
+	activityLayoutMu sync.Mutex
+)
+
+// activityLayoutOf returns the activityLayout of a struct type, it fails when the type does not embed Activity
+func activityLayoutOf(t reflect.Type) (*activityLayout, error) {
+	activityLayoutMu.Lock()
+	defer activityLayoutMu.Unlock()
+
+	if layout, ok := activityLayouts[t]; ok {
+		return layout, nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("expected a struct embedding Activity, got " + t.String())
+	}
+
+	layout := &activityLayout{activityIndex: -1}
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case field.Anonymous && field.Type == activityType:
+			layout.activityIndex = i
+		case field.Anonymous:
+			return nil, errors.New(t.String() + " embeds " + field.Type.String() + ", only Activity can be embedded")
+		case field.PkgPath != "":
+			// unexported fields are not encoded
+		default:
+			fields = append(fields, field)
+			layout.customIndexes = append(layout.customIndexes, i)
+		}
+	}
+	if layout.activityIndex < 0 {
+		return nil, errors.New(t.String() + " does not embed Activity")
+	}
+	layout.customType = reflect.StructOf(fields)
+
+	activityLayouts[t] = layout
+	return layout, nil
+}
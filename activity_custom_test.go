@@ -0,0 +1,159 @@
+package getstream_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	getstream "github.com/GetStream/stream-go"
+	"github.com/GetStream/stream-go/getstreamtest"
+)
+
+type rankedPost struct {
+	getstream.Activity
+	Popularity int               `json:"popularity"`
+	Score      float64           `json:"score"`
+	Title      string            `json:"title,omitempty"`
+	Tags       []string          `json:"tags"`
+	Location   map[string]string `json:"location"`
+	Internal   string            `json:"-"`
+	hidden     string
+}
+
+func TestMarshalActivity(t *testing.T) {
+	post := &rankedPost{
+		Activity:   getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:1", Extra: map[string]interface{}{"popularity": 1, "lang": "en"}},
+		Popularity: 42,
+		Score:      0.5,
+		Tags:       []string{"go"},
+		Internal:   "secret",
+		hidden:     "secret",
+	}
+
+	payload, err := getstream.MarshalActivity(post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]interface{}
+	json.Unmarshal(payload, &fields)
+	if fields["actor"] != "user:bob" || fields["verb"] != "post" {
+		t.Error("expected the activity fields, got:", fields)
+	}
+	if fields["popularity"] != float64(42) || fields["score"] != 0.5 || fields["lang"] != "en" {
+		t.Error("expected the custom fields to be numbers, got:", fields)
+	}
+	if _, ok := fields["title"]; ok {
+		t.Error("expected omitempty to be honored")
+	}
+	if _, ok := fields["Internal"]; ok {
+		t.Error("expected fields tagged - to be left out")
+	}
+	if _, ok := fields["hidden"]; ok {
+		t.Error("expected unexported fields to be left out")
+	}
+}
+
+func TestUnmarshalActivity(t *testing.T) {
+	payload := []byte(`{"id":"1","actor":"user:bob","verb":"post","object":"post:1","time":"2017-01-02T03:04:05.000006",
+		"popularity":42,"score":0.5,"title":"hello","tags":["go","stream"],"location":{"city":"Amsterdam"}}`)
+
+	var post rankedPost
+	err := getstream.UnmarshalActivity(payload, &post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if post.ID != "1" || post.Actor != "user:bob" || post.TimeStamp == nil {
+		t.Error("expected the activity fields, got:", post.Activity)
+	}
+	if post.Popularity != 42 || post.Score != 0.5 || post.Title != "hello" {
+		t.Error("unexpected custom fields:", post.Popularity, post.Score, post.Title)
+	}
+	if len(post.Tags) != 2 || post.Location["city"] != "Amsterdam" {
+		t.Error("unexpected custom fields:", post.Tags, post.Location)
+	}
+	if post.Extra["popularity"] != json.Number("42") {
+		t.Error("expected the custom fields in Extra too, got:", post.Extra)
+	}
+}
+
+func TestActivityStructErrors(t *testing.T) {
+	type notAnActivity struct {
+		Popularity int `json:"popularity"`
+	}
+	type embedsOther struct {
+		getstream.Activity
+		notAnActivity
+	}
+
+	if _, err := getstream.MarshalActivity(notAnActivity{}); err == nil {
+		t.Error("expected a struct without Activity to be rejected")
+	}
+	if _, err := getstream.MarshalActivity(embedsOther{}); err == nil {
+		t.Error("expected a struct embedding another type to be rejected")
+	}
+	if _, err := getstream.MarshalActivity(nil); err == nil {
+		t.Error("expected nil to be rejected")
+	}
+	if _, err := getstream.MarshalActivity((*rankedPost)(nil)); err == nil {
+		t.Error("expected a nil pointer to be rejected")
+	}
+	if err := getstream.UnmarshalActivity([]byte(`{}`), rankedPost{}); err == nil {
+		t.Error("expected a non pointer to be rejected")
+	}
+	if err := getstream.UnmarshalActivity([]byte(`{"popularity":"high"}`), &rankedPost{}); err == nil {
+		t.Error("expected a custom field of the wrong type to be rejected")
+	}
+}
+
+func TestActivityExtraRoundTrip(t *testing.T) {
+	server := getstreamtest.NewServer("key", "secret")
+	defer server.Close()
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, _ := client.FlatFeed("user", "bob")
+
+	_, err = feed.AddActivity(&getstream.Activity{
+		Actor:  "user:bob",
+		Verb:   "post",
+		Object: "post:1",
+		Extra: map[string]interface{}{
+			"popularity": 9007199254740993,
+			"featured":   true,
+			"tags":       []string{"go"},
+			"location":   map[string]interface{}{"lat": 52.37},
+		},
+		MetaData: map[string]string{"lang": "en"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := feed.Activities(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := output.Activities[0].Extra
+	if extra["popularity"] != json.Number("9007199254740993") || extra["featured"] != true {
+		t.Error("expected the custom fields to round-trip, got:", extra)
+	}
+	if tags, _ := extra["tags"].([]interface{}); len(tags) != 1 || tags[0] != "go" {
+		t.Error("expected the array to round-trip, got:", extra["tags"])
+	}
+	if location, _ := extra["location"].(map[string]interface{}); location["lat"] != json.Number("52.37") {
+		t.Error("expected the object to round-trip, got:", extra["location"])
+	}
+
+	metadata := output.Activities[0].MetaData
+	if metadata["lang"] != "en" {
+		t.Error("expected the string fields in MetaData, got:", metadata)
+	}
+	if _, ok := metadata["popularity"]; ok {
+		t.Error("expected MetaData to hold strings only, got:", metadata)
+	}
+	if _, ok := extra["lang"]; ok {
+		t.Error("expected Extra to hold no strings, got:", extra)
+	}
+}
@@ -1,8 +1,10 @@
 package getstream_test
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	getstream "github.com/GetStream/stream-go"
 	"github.com/pborman/uuid"
@@ -46,6 +48,36 @@ func TestActivityUnmarshall(t *testing.T) {
 	}
 }
 
+func TestActivityMetaDataRoundTrip(t *testing.T) {
+	activity := &getstream.Activity{}
+	payload := []byte(`{"actor":"flat:john","verb":"post","object":"flat:eric","color":"red","size":"xl","score":3}`)
+
+	err := activity.UnmarshalJSON(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	activity.MetaData["color"] = "blue"
+	delete(activity.MetaData, "size")
+
+	payload, err = activity.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]interface{}
+	json.Unmarshal(payload, &fields)
+	if fields["color"] != "blue" {
+		t.Error("expected the edited MetaData to be sent, got:", fields["color"])
+	}
+	if _, ok := fields["size"]; ok {
+		t.Error("expected the deleted MetaData key to be left out, got:", fields["size"])
+	}
+	if fields["score"] != float64(3) {
+		t.Error("expected Extra to be sent, got:", fields["score"])
+	}
+}
+
 func TestActivityUnmarshallEmptyPayload(t *testing.T) {
 	activity := &getstream.Activity{}
 
@@ -83,6 +115,57 @@ func TestActivityUnmarshallBadPayloadTime(t *testing.T) {
 	}
 }
 
+func TestActivityMarshallTime(t *testing.T) {
+	local := time.Date(2017, 1, 2, 3, 4, 5, 6000, time.FixedZone("UTC-5", -5*60*60))
+
+	for _, test := range []struct {
+		activity getstream.Activity
+		expected string
+	}{
+		{getstream.Activity{TimeStamp: &local}, "2017-01-02T08:04:05.000006"},
+		{getstream.Activity{TimeStamp: &local, ServerTime: true}, "2017-01-02T08:04:05.000006"},
+		{getstream.Activity{ServerTime: true}, ""},
+	} {
+		payload, err := test.activity.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]interface{}
+		json.Unmarshal(payload, &fields)
+		if value, _ := fields["time"].(string); value != test.expected {
+			t.Errorf("expected time %q, got %q", test.expected, value)
+		}
+	}
+
+	payload, _ := getstream.Activity{}.MarshalJSON()
+	activity := &getstream.Activity{}
+	activity.UnmarshalJSON(payload)
+	if activity.TimeStamp == nil || time.Since(*activity.TimeStamp) > time.Minute || time.Since(*activity.TimeStamp) < -time.Minute {
+		t.Error("expected the current time in UTC when TimeStamp is nil, got", activity.TimeStamp)
+	}
+}
+
+func TestActivityUnmarshallTime(t *testing.T) {
+	expected := time.Date(2016, 9, 22, 21, 44, 58, 821577000, time.UTC)
+
+	for _, value := range []string{
+		"2016-09-22T21:44:58.821577",
+		"2016-09-22T21:44:58.821577Z",
+		"2016-09-22T23:44:58.821577+02:00",
+		"2016-09-22 21:44:58.821577",
+		"2016-09-22 21:44:58.821577+00:00",
+	} {
+		activity := &getstream.Activity{}
+		err := activity.UnmarshalJSON([]byte(`{"actor":"flat:john","verb":"post","object":"flat:eric","time":"` + value + `"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if activity.TimeStamp == nil || !activity.TimeStamp.Equal(expected) || activity.TimeStamp.Location() != time.UTC {
+			t.Error(value, "expected", expected, "got", activity.TimeStamp)
+		}
+	}
+}
+
 func TestActivityUnmarshallBadPayloadTo(t *testing.T) {
 	var err error
 	activity := &getstream.Activity{}
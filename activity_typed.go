@@ -0,0 +1,227 @@
+//go:build go1.18
+// +build go1.18
+
+package getstream
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrNoPayload : the activity has no data to decode
+	ErrNoPayload = errors.New("getstream: activity has no data")
+	// ErrUnknownVerb : no payload type is registered for the verb of the activity
+	ErrUnknownVerb = errors.New("getstream: no payload type registered for verb")
+)
+
+// PayloadError is the error decoding the Data of an activity
+type PayloadError struct {
+	ActivityID string
+	Verb       string
+	Err        error
+}
+
+var _ error = &PayloadError{}
+
+func (e *PayloadError) Error() string {
+	return "activity " + e.ActivityID + " (" + e.Verb + "): " + e.Err.Error()
+}
+
+// Unwrap returns the decoding error
+func (e *PayloadError) Unwrap() error {
+	return e.Err
+}
+
+// TypedActivity is an Activity along with its Data decoded into a T
+// Err is set, and Payload left to its zero value, when the Data could not be decoded
+type TypedActivity[T any] struct {
+	*Activity
+	Payload T
+	Err     error
+}
+
+// MarshalJSON encodes the activity, its payload and the message of Err
+// Without it the MarshalJSON of the embedded Activity would encode the activity alone, and panic when it is nil
+func (t TypedActivity[T]) MarshalJSON() ([]byte, error) {
+	output := struct {
+		Activity *Activity `json:"activity"`
+		Payload  T         `json:"payload"`
+		Err      string    `json:"error,omitempty"`
+	}{
+		Activity: t.Activity,
+		Payload:  t.Payload,
+	}
+	if t.Err != nil {
+		output.Err = t.Err.Error()
+	}
+	return json.Marshal(output)
+}
+
+// TypedAggregatedGroup is an AggregatedFeedGroup with its activities decoded
+type TypedAggregatedGroup[T any] struct {
+	*AggregatedFeedGroup
+	Activities []TypedActivity[T]
+}
+
+// TypedNotificationGroup is a NotificationFeedGroup with its activities decoded
+type TypedNotificationGroup[T any] struct {
+	*NotificationFeedGroup
+	Activities []TypedActivity[T]
+}
+
+// DecodeActivity decodes the Data of an activity into a T
+func DecodeActivity[T any](activity *Activity) TypedActivity[T] {
+	return decodeActivity(activity, decodePayload[T])
+}
+
+// DecodeActivities decodes the Data of activities into a T
+// A failure is reported on the activity it happened for, the other activities are still decoded
+func DecodeActivities[T any](activities []*Activity) []TypedActivity[T] {
+	return decodeActivities(activities, decodePayload[T])
+}
+
+// DecodeFlatFeed decodes the Data of the activities of a flat feed page into a T
+func DecodeFlatFeed[T any](output *GetFlatFeedOutput) []TypedActivity[T] {
+	return decodeActivities(output.Activities, decodePayload[T])
+}
+
+// DecodeAggregatedFeed decodes the Data of the activities of an aggregated feed page into a T
+func DecodeAggregatedFeed[T any](output *GetAggregatedFeedOutput) []TypedAggregatedGroup[T] {
+	return decodeAggregatedGroups(output.Results, decodePayload[T])
+}
+
+// DecodeNotificationFeed decodes the Data of the activities of a notification feed page into a T
+func DecodeNotificationFeed[T any](output *GetNotificationFeedOutput) []TypedNotificationGroup[T] {
+	return decodeNotificationGroups(output.Results, decodePayload[T])
+}
+
+// PayloadRegistry decodes the Data of activities into a type picked by their Verb, for feeds mixing several kinds of activities
+//
+//	registry := getstream.NewPayloadRegistry()
+//	getstream.RegisterPayload[Post](registry, "post")
+//	getstream.RegisterPayload[Like](registry, "like")
+//
+//	for _, activity := range registry.DecodeFlatFeed(output) {
+//		switch payload := activity.Payload.(type) {
+//		case Post:
+//		case Like:
+//		}
+//	}
+//
+// The payloads are values of the registered types. A PayloadRegistry is safe for concurrent use.
+type PayloadRegistry struct {
+	mu       sync.RWMutex
+	decoders map[string]func(json.RawMessage) (any, error)
+}
+
+// NewPayloadRegistry returns an empty PayloadRegistry
+func NewPayloadRegistry() *PayloadRegistry {
+	return &PayloadRegistry{
+		decoders: make(map[string]func(json.RawMessage) (any, error)),
+	}
+}
+
+// RegisterPayload makes the registry decode the Data of activities with the verb into a T
+func RegisterPayload[T any](registry *PayloadRegistry, verb string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.decoders[verb] = func(data json.RawMessage) (any, error) {
+		var payload T
+		err := json.Unmarshal(data, &payload)
+		return payload, err
+	}
+}
+
+// Decode decodes the Data of an activity into the type registered for its verb
+func (r *PayloadRegistry) Decode(activity *Activity) (any, error) {
+	decode := r.decoder(activity.Verb)
+	if decode == nil {
+		return nil, ErrUnknownVerb
+	}
+	if activity.Data == nil {
+		return nil, ErrNoPayload
+	}
+	return decode(*activity.Data)
+}
+
+// DecodeActivities decodes the Data of activities into the types registered for their verbs
+func (r *PayloadRegistry) DecodeActivities(activities []*Activity) []TypedActivity[any] {
+	return decodeActivities(activities, r.Decode)
+}
+
+// DecodeFlatFeed decodes the Data of the activities of a flat feed page into the types registered for their verbs
+func (r *PayloadRegistry) DecodeFlatFeed(output *GetFlatFeedOutput) []TypedActivity[any] {
+	return decodeActivities(output.Activities, r.Decode)
+}
+
+// DecodeAggregatedFeed decodes the Data of the activities of an aggregated feed page into the types registered for their verbs
+func (r *PayloadRegistry) DecodeAggregatedFeed(output *GetAggregatedFeedOutput) []TypedAggregatedGroup[any] {
+	return decodeAggregatedGroups(output.Results, r.Decode)
+}
+
+// DecodeNotificationFeed decodes the Data of the activities of a notification feed page into the types registered for their verbs
+func (r *PayloadRegistry) DecodeNotificationFeed(output *GetNotificationFeedOutput) []TypedNotificationGroup[any] {
+	return decodeNotificationGroups(output.Results, r.Decode)
+}
+
+func (r *PayloadRegistry) decoder(verb string) func(json.RawMessage) (any, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.decoders[verb]
+}
+
+// decodePayload decodes the Data of an activity into a T
+func decodePayload[T any](activity *Activity) (T, error) {
+	var payload T
+	if activity.Data == nil {
+		return payload, ErrNoPayload
+	}
+	err := json.Unmarshal(*activity.Data, &payload)
+	return payload, err
+}
+
+func decodeActivity[T any](activity *Activity, decode func(*Activity) (T, error)) TypedActivity[T] {
+	typed := TypedActivity[T]{Activity: activity}
+
+	payload, err := decode(activity)
+	if err != nil {
+		typed.Err = &PayloadError{ActivityID: activity.ID, Verb: activity.Verb, Err: err}
+		return typed
+	}
+	typed.Payload = payload
+	return typed
+}
+
+func decodeActivities[T any](activities []*Activity, decode func(*Activity) (T, error)) []TypedActivity[T] {
+	result := make([]TypedActivity[T], len(activities))
+	for i, activity := range activities {
+		result[i] = decodeActivity(activity, decode)
+	}
+	return result
+}
+
+func decodeAggregatedGroups[T any](groups []*AggregatedFeedGroup, decode func(*Activity) (T, error)) []TypedAggregatedGroup[T] {
+	result := make([]TypedAggregatedGroup[T], len(groups))
+	for i, group := range groups {
+		result[i] = TypedAggregatedGroup[T]{
+			AggregatedFeedGroup: group,
+			Activities:          decodeActivities(group.Activities, decode),
+		}
+	}
+	return result
+}
+
+func decodeNotificationGroups[T any](groups []*NotificationFeedGroup, decode func(*Activity) (T, error)) []TypedNotificationGroup[T] {
+	result := make([]TypedNotificationGroup[T], len(groups))
+	for i, group := range groups {
+		result[i] = TypedNotificationGroup[T]{
+			NotificationFeedGroup: group,
+			Activities:            decodeActivities(group.Activities, decode),
+		}
+	}
+	return result
+}
@@ -0,0 +1,163 @@
+//go:build go1.18
+// +build go1.18
+
+package getstream_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+type typedPost struct {
+	Title string `json:"title"`
+	Likes int    `json:"likes"`
+}
+
+type typedLike struct {
+	PostID string `json:"post_id"`
+}
+
+func typedActivity(id, verb, data string) *getstream.Activity {
+	activity := &getstream.Activity{ID: id, Actor: "user:bob", Verb: verb, Object: verb + ":" + id}
+	if data != "" {
+		raw := json.RawMessage(data)
+		activity.Data = &raw
+	}
+	return activity
+}
+
+func TestDecodeFlatFeed(t *testing.T) {
+	output := &getstream.GetFlatFeedOutput{
+		Activities: []*getstream.Activity{
+			typedActivity("1", "post", `{"title":"hello","likes":3}`),
+			typedActivity("2", "post", `{"title":42}`),
+			typedActivity("3", "post", ""),
+			typedActivity("4", "post", `{"title":"bye"}`),
+		},
+	}
+
+	activities := getstream.DecodeFlatFeed[typedPost](output)
+	if len(activities) != 4 {
+		t.Fatal("expected 4 activities, got", len(activities))
+	}
+
+	if activities[0].Err != nil || activities[0].Payload != (typedPost{Title: "hello", Likes: 3}) {
+		t.Error("unexpected payload:", activities[0].Payload, activities[0].Err)
+	}
+	if activities[0].ID != "1" || activities[0].Actor != "user:bob" {
+		t.Error("expected the activity fields to be promoted, got:", activities[0].Activity)
+	}
+
+	var payloadErr *getstream.PayloadError
+	if !errors.As(activities[1].Err, &payloadErr) || payloadErr.ActivityID != "2" || payloadErr.Verb != "post" {
+		t.Error("expected a PayloadError for the malformed payload, got", activities[1].Err)
+	}
+	if !errors.Is(activities[2].Err, getstream.ErrNoPayload) {
+		t.Error("expected ErrNoPayload for an activity without data, got", activities[2].Err)
+	}
+	if activities[3].Err != nil || activities[3].Payload.Title != "bye" {
+		t.Error("expected the activities after a failure to be decoded, got:", activities[3].Payload, activities[3].Err)
+	}
+}
+
+func TestTypedActivityMarshalJSON(t *testing.T) {
+	typed := getstream.DecodeActivity[typedPost](typedActivity("1", "post", `{"title":"hello","likes":3}`))
+
+	payload, err := json.Marshal(typed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output struct {
+		Activity map[string]interface{} `json:"activity"`
+		Payload  typedPost              `json:"payload"`
+		Err      string                 `json:"error"`
+	}
+	if err := json.Unmarshal(payload, &output); err != nil {
+		t.Fatal(err)
+	}
+	if output.Activity["id"] != "1" || output.Payload != (typedPost{Title: "hello", Likes: 3}) || output.Err != "" {
+		t.Error("expected the activity and its payload, got:", string(payload))
+	}
+
+	failed := getstream.DecodeActivity[typedPost](typedActivity("2", "post", ""))
+	payload, err = json.Marshal(failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(payload, &output); err != nil || output.Err == "" {
+		t.Error("expected the error message, got:", string(payload))
+	}
+
+	if payload, err = json.Marshal(getstream.TypedActivity[typedPost]{}); err != nil {
+		t.Fatal("expected a TypedActivity without an activity to be encoded, got:", err)
+	}
+}
+
+func TestDecodeAggregatedFeed(t *testing.T) {
+	output := &getstream.GetAggregatedFeedOutput{
+		Results: []*getstream.AggregatedFeedGroup{
+			{ID: "group1", Verb: "post", ActivityCount: 2, Activities: []*getstream.Activity{
+				typedActivity("1", "post", `{"title":"first"}`),
+				typedActivity("2", "post", `{"title":"second"}`),
+			}},
+		},
+	}
+
+	groups := getstream.DecodeAggregatedFeed[typedPost](output)
+	if len(groups) != 1 || groups[0].ID != "group1" || groups[0].ActivityCount != 2 {
+		t.Fatal("expected the group fields to be kept, got:", groups)
+	}
+	if len(groups[0].Activities) != 2 || groups[0].Activities[1].Payload.Title != "second" {
+		t.Error("unexpected activities:", groups[0].Activities)
+	}
+}
+
+func TestDecodeNotificationFeed(t *testing.T) {
+	output := &getstream.GetNotificationFeedOutput{
+		Results: []*getstream.NotificationFeedGroup{
+			{ID: "group1", Verb: "like", IsSeen: true, Activities: []*getstream.Activity{
+				typedActivity("1", "like", `{"post_id":"post:1"}`),
+			}},
+		},
+	}
+
+	groups := getstream.DecodeNotificationFeed[typedLike](output)
+	if len(groups) != 1 || !groups[0].IsSeen {
+		t.Fatal("expected the group fields to be kept, got:", groups)
+	}
+	if len(groups[0].Activities) != 1 || groups[0].Activities[0].Payload.PostID != "post:1" {
+		t.Error("unexpected activities:", groups[0].Activities)
+	}
+}
+
+func TestPayloadRegistry(t *testing.T) {
+	registry := getstream.NewPayloadRegistry()
+	getstream.RegisterPayload[typedPost](registry, "post")
+	getstream.RegisterPayload[typedLike](registry, "like")
+
+	output := &getstream.GetFlatFeedOutput{
+		Activities: []*getstream.Activity{
+			typedActivity("1", "post", `{"title":"hello"}`),
+			typedActivity("2", "like", `{"post_id":"post:1"}`),
+			typedActivity("3", "share", `{}`),
+			typedActivity("4", "like", `[]`),
+		},
+	}
+
+	activities := registry.DecodeFlatFeed(output)
+	if post, ok := activities[0].Payload.(typedPost); !ok || post.Title != "hello" {
+		t.Errorf("expected a typedPost, got %#v", activities[0].Payload)
+	}
+	if like, ok := activities[1].Payload.(typedLike); !ok || like.PostID != "post:1" {
+		t.Errorf("expected a typedLike, got %#v", activities[1].Payload)
+	}
+	if !errors.Is(activities[2].Err, getstream.ErrUnknownVerb) || activities[2].Payload != nil {
+		t.Error("expected ErrUnknownVerb for an unregistered verb, got", activities[2].Err)
+	}
+	if activities[3].Err == nil {
+		t.Error("expected an error for a malformed payload")
+	}
+}
@@ -2,12 +2,14 @@ package getstream
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/LeisureLink/httpsig.v1"
@@ -19,6 +21,21 @@ type Client struct {
 	BaseURL *url.URL // https://api.getstream.io/api/
 	Config  *Config
 	Signer  *Signer
+	Retry   *RetryPolicy // nil disables retries
+
+	// Instrumentation receives an event for every request, nil disables it
+	Instrumentation Instrumentation
+
+	// Logger receives a line for every request, nil disables logging
+	Logger Logger
+
+	// WaitOnRateLimit makes requests wait for the rate limit to reset
+	// when the last response for an endpoint reported no remaining requests
+	WaitOnRateLimit bool
+
+	middlewares  []Middleware
+	rateLimitsMu sync.Mutex
+	rateLimits   map[string]RateLimit
 }
 
 /**
@@ -28,11 +45,13 @@ type Client struct {
  *   cfg, pointer to a Config structure which takes the API credentials, Location, etc
  * Returns:
  *   Client struct
+ *
+ * The Config is copied along with its RetryPolicy, SecondarySecrets and BaseURL, the Client never modifies it and
+ * later changes to it do not reach the Client; the HTTPClient, Transport and TLSConfig are shared.
  */
-func New(cfg *Config) (*Client, error) {
-	var (
-		timeout int64
-	)
+func New(config *Config) (*Client, error) {
+	// work on a deep copy, so a Config can be reused for several clients
+	cfg := config.clone()
 
 	if cfg.APIKey == "" {
 		return nil, errors.New("Required API Key was not set")
@@ -42,36 +61,14 @@ func New(cfg *Config) (*Client, error) {
 		return nil, errors.New("API Secret or Token was not set, one or the other is required")
 	}
 
-	if cfg.TimeoutInt <= 0 {
-		timeout = 3
-	} else {
-		timeout = cfg.TimeoutInt
-	}
-	cfg.SetTimeout(timeout)
-
 	if cfg.Version == "" {
 		cfg.Version = "v1.0"
 	}
 
-	location := "api"
-	port := ""
-	secure := "s"
-	if cfg.Location != "" {
-		location = cfg.Location + "-api"
-		if cfg.Location == "qa" {
-			secure = ""
-		}
-		if cfg.Location == "localhost" {
-			port = ":8000"
-			secure = ""
-		}
-	}
-
-	baseURL, err := url.Parse("http" + secure + "://" + location + ".getstream.io" + port + "/api/" + cfg.Version + "/")
+	baseURL, err := cfg.baseURL()
 	if err != nil {
 		return nil, err
 	}
-	cfg.SetBaseURL(baseURL)
 
 	var signer *Signer
 	if cfg.Token != "" {
@@ -84,18 +81,29 @@ func New(cfg *Config) (*Client, error) {
 		// build the Signature based on the API Secret
 		cfg.SetToken("")
 		signer = &Signer{
-			Secret: cfg.APISecret,
+			Secret:           cfg.APISecret,
+			SecondarySecrets: cfg.SecondarySecrets,
+		}
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Transport: cfg.transport(),
+			Timeout:   cfg.timeout(),
 		}
 	}
 
 	client := &Client{
-		HTTP: &http.Client{
-			Transport: GETSTREAM_TRANSPORT,
-			Timeout:   cfg.TimeoutDuration,
-		},
+		HTTP:    httpClient,
 		BaseURL: baseURL,
-		Config:  cfg,
+		Config:  &cfg,
 		Signer:  signer,
+		Retry:   cfg.Retry,
+
+		Instrumentation: cfg.Instrumentation,
+		Logger:          cfg.Logger,
+		WaitOnRateLimit: cfg.WaitOnRateLimit,
 	}
 
 	return client, nil
@@ -210,24 +218,49 @@ func ConvertUUIDToWord(uuid string) string {
 }
 
 // get request helper
-func (c *Client) get(f Feed, path string, payload []byte, params map[string]string) ([]byte, error) {
+func (c *Client) get(ctx context.Context, f Feed, path string, payload []byte, params map[string]string) ([]byte, error) {
 	// we force an empty body payload because GET requests cannot have a body with our API
-	return c.request(f, "GET", path, []byte{}, params)
+	return c.request(ctx, f, "GET", path, []byte{}, params)
 }
 
 // post request helper
-func (c *Client) post(f Feed, path string, payload []byte, params map[string]string) ([]byte, error) {
-	return c.request(f, "POST", path, payload, params)
+func (c *Client) post(ctx context.Context, f Feed, path string, payload []byte, params map[string]string) ([]byte, error) {
+	return c.request(ctx, f, "POST", path, payload, params)
 }
 
 // delete request helper
-func (c *Client) del(f Feed, path string, payload []byte, params map[string]string) error {
-	_, err := c.request(f, "DELETE", path, payload, params)
+func (c *Client) del(ctx context.Context, f Feed, path string, payload []byte, params map[string]string) error {
+	_, err := c.request(ctx, f, "DELETE", path, payload, params)
 	return err
 }
 
 // request helper
-func (c *Client) request(f Feed, method string, path string, payload []byte, params map[string]string) ([]byte, error) {
+// the context is attached to the http request so deadlines and cancellation propagate to the wire
+func (c *Client) request(ctx context.Context, f Feed, method string, path string, payload []byte, params map[string]string) ([]byte, error) {
+	instrumentation := c.Instrumentation
+	if instrumentation == nil {
+		instrumentation = NopInstrumentation{}
+	}
+
+	event := RequestEvent{
+		Method:   method,
+		Endpoint: endpointName(path),
+		Start:    time.Now(),
+	}
+	ctx = instrumentation.StartRequest(ctx, event.Method, event.Endpoint)
+
+	body, err := c.send(ctx, f, method, path, payload, params, &event)
+
+	event.Latency = time.Since(event.Start)
+	event.Err = err
+	instrumentation.EndRequest(ctx, event)
+	c.logRequest(event)
+
+	return body, err
+}
+
+// send performs a request including its retries, and fills the event with what happened on the wire
+func (c *Client) send(ctx context.Context, f Feed, method string, path string, payload []byte, params map[string]string, event *RequestEvent) ([]byte, error) {
 	apiUrl, err := url.Parse(path)
 	if err != nil {
 		return nil, err
@@ -240,22 +273,17 @@ func (c *Client) request(f Feed, method string, path string, payload []byte, par
 	query = c.setRequestParams(query, params)
 	apiUrl.RawQuery = query.Encode()
 
-	// create a new http request
-	req, err := http.NewRequest(method, apiUrl.String(), bytes.NewBuffer(payload))
-	if err != nil {
-		return nil, err
-	}
-
-	// set the Auth headers for the http request
-	c.setBaseHeaders(req)
-
 	auth := ""
 	sig := ""
 	switch {
 	case path == "follow_many/": // one feed follows many feeds
 		auth = "app"
 		sig = "sig"
-	case path == "activities/": // batch activities methods
+	case path == "activities/" || path == "activity/": // batch activities methods and partial updates
+		// feed auth
+		auth = "feed"
+		sig = "jwt"
+	case path == "stats/follow/": // follower and following counts
 		// feed auth
 		auth = "feed"
 		sig = "jwt"
@@ -272,33 +300,90 @@ func (c *Client) request(f Feed, method string, path string, payload []byte, par
 		sig = "sig"
 	}
 
-	c.setAuthSigAndHeaders(req, f, auth, sig, path)
+	endpoint := rateLimitKey(method, event.Endpoint)
+
+	attempts := c.Retry.attempts(ctx, method)
+	for attempt := 1; ; attempt++ {
+		event.Retries = attempt - 1
+
+		if c.WaitOnRateLimit {
+			if err := c.waitForRateLimit(ctx, endpoint); err != nil {
+				return nil, err
+			}
+		}
+
+		// create a new http request, every attempt gets a fresh body, Date header and signature
+		req, err := http.NewRequest(method, apiUrl.String(), bytes.NewBuffer(payload))
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+
+		// set the Auth headers for the http request
+		c.setBaseHeaders(req)
+		if err := c.setAuthSigAndHeaders(req, f, auth, sig, path); err != nil {
+			return nil, err
+		}
+
+		// perform the http request
+		event.BytesSent += len(payload)
+		resp, body, err := c.do(req)
+		if err == nil {
+			event.StatusCode = resp.StatusCode
+			event.BytesReceived = len(body)
+			event.APIDuration = parseAPIDuration(body)
+
+			if rateLimit, ok := parseRateLimit(resp.Header); ok {
+				c.setRateLimit(endpoint, rateLimit)
+			}
+		}
 
-	// perform the http request
-	resp, err := c.HTTP.Do(req)
+		if attempt < attempts {
+			if delay, ok := c.Retry.retryDelay(attempt, resp, err); ok {
+				if err := sleepContext(ctx, delay); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		if err != nil {
+			return nil, &TransportError{Err: err}
+		}
+
+		// handle the response
+		switch {
+		case resp.StatusCode/100 == 2: // SUCCESS
+			return body, nil
+		default:
+			respErr := newResponseError(resp.StatusCode, body)
+			if resp.StatusCode == http.StatusTooManyRequests {
+				rateLimit, _ := parseRateLimit(resp.Header)
+				return nil, &RateLimitError{
+					Err:       respErr,
+					RateLimit: rateLimit,
+				}
+			}
+			return nil, respErr
+		}
+	}
+}
+
+// do performs a single http request through the middlewares and reads the whole response body
+func (c *Client) do(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := c.roundTrip()(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	// read the response
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// handle the response
-	switch {
-	case resp.StatusCode/100 == 2: // SUCCESS
-		return body, nil
-	default:
-		var respErr Error
-		err = json.Unmarshal(body, &respErr)
-		if err != nil {
-			return nil, err
-		}
-		return nil, &respErr
-	}
+	return resp, body, nil
 }
 
 func (c *Client) setStandardParams(query url.Values) url.Values {
@@ -335,18 +420,27 @@ func (c *Client) setBaseHeaders(request *http.Request) {
 func (c *Client) setAuthSigAndHeaders(request *http.Request, f Feed, auth string, sig string, path string) error {
 	if sig == "jwt" {
 		request.Header.Set("stream-auth-type", "jwt")
-		if f == nil {
+		switch {
+		case path == "activities/" || path == "activity/": // the batch activities methods are not bound to a feed
+			action := ScopeActionWrite
+			if request.Method == http.MethodGet {
+				action = ScopeActionRead
+			}
+			token, err := c.Signer.GenerateFeedScopeToken(ScopeContextActivities, action, "*")
+			if err != nil {
+				return err
+			}
+			request.Header.Set("Authorization", token)
+		case f == nil:
 			request.Header.Set("Authorization", c.Config.Token)
-		} else {
-			if path == "activities/" {
-				token, err := c.Signer.GenerateFeedScopeToken(ScopeContextActivities, ScopeActionWrite, "*")
-				if err != nil {
-					return err
-				}
-				request.Header.Set("Authorization", token)
-			} else {
-				request.Header.Set("Authorization", f.Token())
+		case path == "stats/follow/":
+			token, err := c.Signer.GenerateFeedScopeToken(ScopeContextFollower, ScopeActionRead, "*")
+			if err != nil {
+				return err
 			}
+			request.Header.Set("Authorization", token)
+		default:
+			request.Header.Set("Authorization", f.Token())
 		}
 		return nil
 	}
@@ -405,7 +499,13 @@ type PostActivityToManyInput struct {
 	FeedIDs  []string `json:"feeds"`
 }
 
+// AddActivityToMany adds an Activity to many feeds in a single request
 func (c *Client) AddActivityToMany(activity Activity, feeds []string) error {
+	return c.AddActivityToManyContext(context.Background(), activity, feeds)
+}
+
+// AddActivityToManyContext is the context.Context aware version of AddActivityToMany
+func (c *Client) AddActivityToManyContext(ctx context.Context, activity Activity, feeds []string) error {
 	payload := &PostActivityToManyInput{
 		Activity: activity,
 		FeedIDs:  feeds,
@@ -418,6 +518,11 @@ func (c *Client) AddActivityToMany(activity Activity, feeds []string) error {
 
 	endpoint := "feed/add_to_many/"
 	params := map[string]string{}
-	_, err = c.post(nil, endpoint, final_payload, params)
+	if activity.ForeignID != "" {
+		// the API de-duplicates activities by ForeignID, so the write is safe to retry
+		ctx = withIdempotentWrite(ctx)
+	}
+
+	_, err = c.post(ctx, nil, endpoint, final_payload, params)
 	return err
 }
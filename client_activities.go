@@ -0,0 +1,217 @@
+package getstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxActivitiesPerRequest is the number of activities the activities/ endpoint accepts in a single request
+const maxActivitiesPerRequest = 100
+
+// ForeignIDTime references an activity by its foreign_id and time
+type ForeignIDTime struct {
+	ForeignID string
+	Time      time.Time
+}
+
+// GetActivitiesOutput is the response of GetActivitiesByID and GetActivitiesByForeignID
+// Activities which do not exist are left out of it
+type GetActivitiesOutput struct {
+	Duration   string      `json:"duration"`
+	Activities []*Activity `json:"results"`
+}
+
+// GetActivitiesByID returns the activities with the given IDs, up to 100 at a time
+func (c *Client) GetActivitiesByID(ids ...string) (*GetActivitiesOutput, error) {
+	return c.GetActivitiesByIDContext(context.Background(), ids...)
+}
+
+// GetActivitiesByIDContext is the context.Context aware version of GetActivitiesByID
+func (c *Client) GetActivitiesByIDContext(ctx context.Context, ids ...string) (*GetActivitiesOutput, error) {
+	if err := checkActivitiesCount(len(ids)); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if id == "" {
+			return nil, errors.New("Activity IDs cannot be empty")
+		}
+	}
+
+	params := map[string]string{
+		"ids": strings.Join(ids, ","),
+	}
+	return c.getActivities(ctx, params)
+}
+
+// GetActivitiesByForeignID returns the activities with the given foreign_id and time, up to 100 at a time
+func (c *Client) GetActivitiesByForeignID(refs ...ForeignIDTime) (*GetActivitiesOutput, error) {
+	return c.GetActivitiesByForeignIDContext(context.Background(), refs...)
+}
+
+// GetActivitiesByForeignIDContext is the context.Context aware version of GetActivitiesByForeignID
+func (c *Client) GetActivitiesByForeignIDContext(ctx context.Context, refs ...ForeignIDTime) (*GetActivitiesOutput, error) {
+	if err := checkActivitiesCount(len(refs)); err != nil {
+		return nil, err
+	}
+
+	foreignIDs := make([]string, len(refs))
+	timestamps := make([]string, len(refs))
+	for i, ref := range refs {
+		if ref.ForeignID == "" || ref.Time.IsZero() {
+			return nil, errors.New("Activity references need a ForeignID and a Time")
+		}
+		foreignIDs[i] = ref.ForeignID
+		timestamps[i] = formatActivityTime(ref.Time)
+	}
+
+	params := map[string]string{
+		"foreign_ids": strings.Join(foreignIDs, ","),
+		"timestamps":  strings.Join(timestamps, ","),
+	}
+	return c.getActivities(ctx, params)
+}
+
+func (c *Client) getActivities(ctx context.Context, params map[string]string) (*GetActivitiesOutput, error) {
+	resultBytes, err := c.get(ctx, nil, "activities/", nil, params)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &GetActivitiesOutput{}
+	err = json.Unmarshal(resultBytes, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// UpdateActivityRequest is a partial update of an activity, referenced by ID or by ForeignID and Time
+// Set replaces the value of fields and Unset removes fields, keys can be dotted paths to nested fields like "data.likes"
+type UpdateActivityRequest struct {
+	ID        string
+	ForeignID string
+	Time      time.Time
+	Set       map[string]interface{}
+	Unset     []string
+}
+
+// MarshalJSON is the custom marshal function for UpdateActivityRequest
+// It will be used by json.Marshal()
+func (r UpdateActivityRequest) MarshalJSON() ([]byte, error) {
+	payload := make(map[string]interface{})
+	if r.ID != "" {
+		payload["id"] = r.ID
+	} else {
+		payload["foreign_id"] = r.ForeignID
+		payload["time"] = formatActivityTime(r.Time)
+	}
+	if len(r.Set) > 0 {
+		payload["set"] = r.Set
+	}
+	if len(r.Unset) > 0 {
+		payload["unset"] = r.Unset
+	}
+	return json.Marshal(payload)
+}
+
+func (r UpdateActivityRequest) validate() error {
+	if r.ID == "" && (r.ForeignID == "" || r.Time.IsZero()) {
+		return errors.New("Partial updates need an ID, or a ForeignID and a Time")
+	}
+	if r.ID != "" && r.ForeignID != "" {
+		return errors.New("Partial updates need an ID or a ForeignID, not both")
+	}
+	if len(r.Set) == 0 && len(r.Unset) == 0 {
+		return errors.New("Partial updates need fields to set or unset")
+	}
+	for _, key := range r.Unset {
+		if _, ok := r.Set[key]; ok {
+			return errors.New("Cannot set and unset " + strconv.Quote(key) + " at once")
+		}
+	}
+	return nil
+}
+
+type postPartialUpdateInput struct {
+	Changes []UpdateActivityRequest `json:"changes"`
+}
+
+type postPartialUpdateOutput struct {
+	Duration   string      `json:"duration"`
+	Activities []*Activity `json:"activities"`
+}
+
+// PartialUpdateActivity sets and unsets fields of an activity without sending the whole activity, and returns the updated activity
+func (c *Client) PartialUpdateActivity(change UpdateActivityRequest) (*Activity, error) {
+	return c.PartialUpdateActivityContext(context.Background(), change)
+}
+
+// PartialUpdateActivityContext is the context.Context aware version of PartialUpdateActivity
+func (c *Client) PartialUpdateActivityContext(ctx context.Context, change UpdateActivityRequest) (*Activity, error) {
+	activities, err := c.PartialUpdateActivitiesContext(ctx, change)
+	if err != nil {
+		return nil, err
+	}
+	if len(activities) != 1 {
+		return nil, errors.New("Expected the updated activity in the response")
+	}
+	return activities[0], nil
+}
+
+// PartialUpdateActivities applies partial updates to up to 100 activities in a single request,
+// and returns the updated activities in the order of the changes
+func (c *Client) PartialUpdateActivities(changes ...UpdateActivityRequest) ([]*Activity, error) {
+	return c.PartialUpdateActivitiesContext(context.Background(), changes...)
+}
+
+// PartialUpdateActivitiesContext is the context.Context aware version of PartialUpdateActivities
+func (c *Client) PartialUpdateActivitiesContext(ctx context.Context, changes ...UpdateActivityRequest) ([]*Activity, error) {
+	if len(changes) == 0 {
+		return nil, errors.New("No activities to update")
+	}
+	if len(changes) > maxActivitiesPerRequest {
+		return nil, errors.New("Cannot update more than " + strconv.Itoa(maxActivitiesPerRequest) + " activities at a time")
+	}
+	for _, change := range changes {
+		if err := change.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	final_payload, err := json.Marshal(&postPartialUpdateInput{
+		Changes: changes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// setting and unsetting fields gives the same result when repeated, so the write is safe to retry
+	ctx = withIdempotentWrite(ctx)
+
+	resultBytes, err := c.post(ctx, nil, "activity/", final_payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &postPartialUpdateOutput{}
+	err = json.Unmarshal(resultBytes, output)
+	if err != nil {
+		return nil, err
+	}
+	return output.Activities, nil
+}
+
+// checkActivitiesCount checks the number of activities of a request to the activities/ endpoint
+func checkActivitiesCount(count int) error {
+	if count == 0 {
+		return errors.New("No activities to get")
+	}
+	if count > maxActivitiesPerRequest {
+		return errors.New("Cannot get more than " + strconv.Itoa(maxActivitiesPerRequest) + " activities at a time")
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package getstream_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	getstream "github.com/GetStream/stream-go"
+	"github.com/pborman/uuid"
+)
+
+func TestGetActivities(t *testing.T) {
+	client, err := PreTestSetup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := client.FlatFeed("flat", uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	activities, err := feed.AddActivities([]*getstream.Activity{
+		{Actor: "user:bob", Verb: "post", Object: "post:1", ForeignID: uuid.New(), TimeStamp: &now},
+		{Actor: "user:bob", Verb: "post", Object: "post:2", ForeignID: uuid.New(), TimeStamp: &now},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := activities[0], activities[1]
+
+	byID, err := client.GetActivitiesByID(second.ID, first.ID, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byID.Activities) != 2 || byID.Activities[0].ID != second.ID || byID.Activities[1].Object != "post:1" {
+		t.Error("expected the two existing activities, got:", byID.Activities)
+	}
+
+	byForeignID, err := client.GetActivitiesByForeignID(
+		getstream.ForeignIDTime{ForeignID: first.ForeignID, Time: now},
+		getstream.ForeignIDTime{ForeignID: second.ForeignID, Time: now.In(time.FixedZone("UTC+9", 9*60*60))},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byForeignID.Activities) != 2 || byForeignID.Activities[0].ID != first.ID || byForeignID.Activities[1].ID != second.ID {
+		t.Error("expected the activities matching the foreign ids and time, got:", byForeignID.Activities)
+	}
+
+	// cleanup
+	feed.RemoveActivity(first)
+	feed.RemoveActivity(second)
+}
+
+func TestGetActivitiesValidation(t *testing.T) {
+	client, err := getstream.New(&getstream.Config{
+		APIKey:    "my_key",
+		APISecret: "my_secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tooMany := make([]string, 101)
+	for i := range tooMany {
+		tooMany[i] = uuid.New()
+	}
+
+	for _, test := range []struct {
+		get      func() (*getstream.GetActivitiesOutput, error)
+		expected string
+	}{
+		{func() (*getstream.GetActivitiesOutput, error) { return client.GetActivitiesByID() }, "No activities"},
+		{func() (*getstream.GetActivitiesOutput, error) { return client.GetActivitiesByID(tooMany...) }, "more than 100"},
+		{func() (*getstream.GetActivitiesOutput, error) { return client.GetActivitiesByID("1", "") }, "empty"},
+		{func() (*getstream.GetActivitiesOutput, error) { return client.GetActivitiesByForeignID() }, "No activities"},
+		{func() (*getstream.GetActivitiesOutput, error) {
+			return client.GetActivitiesByForeignID(getstream.ForeignIDTime{ForeignID: "post:1"})
+		}, "ForeignID and a Time"},
+	} {
+		_, err := test.get()
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Error("expected an error about", test.expected, "got", err)
+		}
+	}
+}
+
+func TestPartialUpdateActivity(t *testing.T) {
+	client, err := PreTestSetup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := client.FlatFeed("flat", uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := json.RawMessage(`{"likes":1,"title":"hello"}`)
+	now := time.Now()
+	activity, err := feed.AddActivity(&getstream.Activity{
+		Actor: "user:bob", Verb: "post", Object: "post:1", ForeignID: uuid.New(), TimeStamp: &now,
+		Data:     &data,
+		MetaData: map[string]string{"lang": "en"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := client.PartialUpdateActivity(getstream.UpdateActivityRequest{
+		ID:    activity.ID,
+		Set:   map[string]interface{}{"data.likes": 2, "popularity": 10},
+		Unset: []string{"lang"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != activity.ID || updated.Extra["popularity"] != json.Number("10") {
+		t.Error("expected popularity to be set, got:", updated.Extra)
+	}
+	if _, ok := updated.MetaData["lang"]; ok {
+		t.Error("expected lang to be unset, got:", updated.MetaData)
+	}
+	var payload map[string]interface{}
+	json.Unmarshal(*updated.Data, &payload)
+	if payload["likes"] != float64(2) || payload["title"] != "hello" {
+		t.Error("expected only data.likes to change, got:", payload)
+	}
+
+	updated, err = client.PartialUpdateActivity(getstream.UpdateActivityRequest{
+		ForeignID: activity.ForeignID,
+		Time:      now,
+		Set:       map[string]interface{}{"popularity": 11},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != activity.ID || updated.Extra["popularity"] != json.Number("11") {
+		t.Error("expected the activity to be matched by foreign id and time, got:", updated)
+	}
+
+	// cleanup
+	feed.RemoveActivity(activity)
+}
+
+func TestPartialUpdateActivities(t *testing.T) {
+	client, err := PreTestSetup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := client.FlatFeed("flat", uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	activities, err := feed.AddActivities([]*getstream.Activity{
+		{Actor: "user:bob", Verb: "post", Object: "post:1"},
+		{Actor: "user:bob", Verb: "post", Object: "post:2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := client.PartialUpdateActivities(
+		getstream.UpdateActivityRequest{ID: activities[1].ID, Set: map[string]interface{}{"score": 2}},
+		getstream.UpdateActivityRequest{ID: activities[0].ID, Set: map[string]interface{}{"score": 1}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 2 || updated[0].ID != activities[1].ID || updated[1].Extra["score"] != json.Number("1") {
+		t.Error("expected the updated activities in the order of the changes, got:", updated)
+	}
+
+	output, _ := feed.Activities(nil)
+	for _, activity := range output.Activities {
+		if activity.Extra["score"] == nil {
+			t.Error("expected the feed to show the update, got:", activity.Extra)
+		}
+	}
+
+	// cleanup
+	for _, activity := range activities {
+		feed.RemoveActivity(activity)
+	}
+}
+
+func TestPartialUpdateActivityValidation(t *testing.T) {
+	client, err := getstream.New(&getstream.Config{
+		APIKey:    "my_key",
+		APISecret: "my_secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := map[string]interface{}{"score": 1}
+	for _, test := range []struct {
+		change   getstream.UpdateActivityRequest
+		expected string
+	}{
+		{getstream.UpdateActivityRequest{Set: set}, "an ID, or a ForeignID and a Time"},
+		{getstream.UpdateActivityRequest{ForeignID: "post:1", Set: set}, "an ID, or a ForeignID and a Time"},
+		{getstream.UpdateActivityRequest{ID: "1", ForeignID: "post:1", Time: time.Now(), Set: set}, "not both"},
+		{getstream.UpdateActivityRequest{ID: "1"}, "fields to set or unset"},
+		{getstream.UpdateActivityRequest{ID: "1", Set: set, Unset: []string{"score"}}, "set and unset"},
+	} {
+		_, err := client.PartialUpdateActivity(test.change)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Error("expected an error about", test.expected, "got", err)
+		}
+	}
+
+	if _, err := client.PartialUpdateActivities(); err == nil {
+		t.Error("expected an error without changes")
+	}
+}
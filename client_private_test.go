@@ -1,8 +1,12 @@
 package getstream
 
 import (
+	"context"
+	"net/http"
 	"net/url"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestClientRequestBadPath(t *testing.T) {
@@ -16,7 +20,7 @@ func TestClientRequestBadPath(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err = client.request(nil, "get", ":hfi", []byte{}, map[string]string{})
+	_, err = client.request(context.Background(), nil, "get", ":hfi", []byte{}, map[string]string{})
 	if err.Error() != "parse :hfi: missing protocol scheme" {
 		t.Fatal("Expected error about bad URL path mismatch, got:", err.Error())
 	}
@@ -90,3 +94,83 @@ func TestClientSetRequestParams(t *testing.T) {
 		t.Error("foo key didn't set as a URL param as expected, got:", query["foo"][0])
 	}
 }
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+	}
+
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, delay := range expected {
+		if got := policy.backoff(i + 1); got != delay*time.Millisecond {
+			t.Errorf("retry %d: expected %s, got %s", i+1, delay*time.Millisecond, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		got := policy.backoff(1)
+		if got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatal("Expected jittered delay between 50ms and 100ms, got:", got)
+		}
+	}
+}
+
+func TestRetryDelayCapped(t *testing.T) {
+	policy := &RetryPolicy{
+		BaseDelay:         100 * time.Millisecond,
+		MaxDelay:          time.Second,
+		RetryStatusCodes:  []int{http.StatusTooManyRequests},
+		RespectRetryAfter: true,
+	}
+
+	retryAfter := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	retryAfter.Header.Set("Retry-After", "3600")
+	if delay, ok := policy.retryDelay(1, retryAfter, nil); !ok || delay != time.Second {
+		t.Error("Expected the Retry-After delay to be capped at 1s, got:", delay, ok)
+	}
+
+	reset := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	reset.Header.Set("X-RateLimit-Limit", "100")
+	reset.Header.Set("X-RateLimit-Remaining", "0")
+	reset.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	if delay, ok := policy.retryDelay(1, reset, nil); !ok || delay != time.Second {
+		t.Error("Expected the rate limit reset wait to be capped at 1s, got:", delay, ok)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if delay, ok := parseRetryAfter("3"); !ok || delay != 3*time.Second {
+		t.Error("Expected 3s, got:", delay, ok)
+	}
+	if _, ok := parseRetryAfter(""); ok {
+		t.Error("Expected an empty header to be ignored")
+	}
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Error("Expected an invalid header to be ignored")
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if delay, ok := parseRetryAfter(date); !ok || delay <= 59*time.Minute {
+		t.Error("Expected about an hour, got:", delay, ok)
+	}
+}
+
+func TestEndpointName(t *testing.T) {
+	paths := map[string]string{
+		"feed/user/bob/":                          "feed/{slug}/{id}/",
+		"feed/user/bob/123-456/":                  "feed/{slug}/{id}/{activity_id}/",
+		"feed/user/bob/following/":                "feed/{slug}/{id}/following/",
+		"feed/user/bob/following/timeline:alice/": "feed/{slug}/{id}/following/{target}/",
+		"feed/user/bob/followers/":                "feed/{slug}/{id}/followers/",
+		"feed/add_to_many/":                       "feed/add_to_many/",
+		"follow_many/":                            "follow_many/",
+		"activities/":                             "activities/",
+	}
+
+	for path, expected := range paths {
+		if name := endpointName(path); name != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, name)
+		}
+	}
+}
@@ -1,7 +1,15 @@
 package getstream_test
 
 import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	getstream "github.com/GetStream/stream-go"
 	"github.com/pborman/uuid"
@@ -360,6 +368,290 @@ func TestClientBaseURL(t *testing.T) {
 	}
 }
 
+func TestClientRegions(t *testing.T) {
+	for location, expected := range map[string]string{
+		"":             "https://api.getstream.io/api/v1.0/",
+		"us-east":      "https://us-east-api.getstream.io/api/v1.0/",
+		"us-west":      "https://us-west-api.getstream.io/api/v1.0/",
+		"eu-central":   "https://eu-central-api.getstream.io/api/v1.0/",
+		"ap-northeast": "https://ap-northeast-api.getstream.io/api/v1.0/",
+		"ap-southeast": "https://ap-southeast-api.getstream.io/api/v1.0/",
+		"dublin":       "https://dublin-api.getstream.io/api/v1.0/",
+		"singapore":    "https://singapore-api.getstream.io/api/v1.0/",
+		"qa":           "http://qa-api.getstream.io/api/v1.0/",
+		"localhost":    "http://localhost-api.getstream.io:8000/api/v1.0/",
+	} {
+		client, err := getstream.New(&getstream.Config{
+			APIKey:    "my_key",
+			APISecret: "my_secret",
+			Location:  location,
+		})
+		if err != nil {
+			t.Fatal(location, err)
+		}
+		if client.BaseURL.String() != expected {
+			t.Error(location, "expected", expected, "got", client.BaseURL.String())
+		}
+	}
+}
+
+func TestClientBaseURLOverride(t *testing.T) {
+	for baseURL, expected := range map[string]string{
+		"http://127.0.0.1:9000":                   "http://127.0.0.1:9000/api/v2.0/",
+		"http://127.0.0.1:9000/":                  "http://127.0.0.1:9000/api/v2.0/",
+		"https://proxy.internal/stream/api/v1.0":  "https://proxy.internal/stream/api/v1.0/",
+		"https://proxy.internal/stream/api/v1.0/": "https://proxy.internal/stream/api/v1.0/",
+	} {
+		override, _ := url.Parse(baseURL)
+		cfg := &getstream.Config{
+			APIKey:    "my_key",
+			APISecret: "my_secret",
+			Location:  "us-east",
+			Version:   "v2.0",
+			BaseURL:   override,
+		}
+
+		client, err := getstream.New(cfg)
+		if err != nil {
+			t.Fatal(baseURL, err)
+		}
+		if client.BaseURL.String() != expected {
+			t.Error(baseURL, "expected", expected, "got", client.BaseURL.String())
+		}
+		if cfg.BaseURL.String() != baseURL {
+			t.Error("expected the BaseURL of the config to be left untouched, got", cfg.BaseURL.String())
+		}
+	}
+}
+
+func TestClientBaseURLValidation(t *testing.T) {
+	for _, baseURL := range []string{
+		"ftp://127.0.0.1:9000/api/v1.0/",
+		"/api/v1.0/",
+		"localhost:9000",
+		"http:///api/v1.0/",
+		"http://127.0.0.1:9000/api/v1.0/?api_key=key",
+		"http://127.0.0.1:9000/api/v1.0/#fragment",
+	} {
+		override, _ := url.Parse(baseURL)
+		_, err := getstream.New(&getstream.Config{
+			APIKey:    "my_key",
+			APISecret: "my_secret",
+			BaseURL:   override,
+		})
+		if err == nil {
+			t.Error("expected", baseURL, "to be rejected")
+		}
+	}
+}
+
+func TestClientTimeouts(t *testing.T) {
+	for _, test := range []struct {
+		timeoutInt      int64
+		timeoutDuration time.Duration
+		expected        time.Duration
+	}{
+		{0, 0, 3 * time.Second},
+		{5, 0, 5 * time.Second},
+		{5, 250 * time.Millisecond, 250 * time.Millisecond},
+		{0, -1, 0},
+	} {
+		cfg := &getstream.Config{
+			APIKey:          "my_key",
+			APISecret:       "my_secret",
+			TimeoutInt:      test.timeoutInt,
+			TimeoutDuration: test.timeoutDuration,
+		}
+		client, err := getstream.New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if client.HTTP.Timeout != test.expected {
+			t.Error(test.timeoutInt, test.timeoutDuration, "expected a timeout of", test.expected, "got", client.HTTP.Timeout)
+		}
+		if cfg.TimeoutDuration != test.timeoutDuration {
+			t.Error("expected the TimeoutDuration of the config to be left untouched, got", cfg.TimeoutDuration)
+		}
+	}
+}
+
+func TestClientTransportPerClient(t *testing.T) {
+	cfg := &getstream.Config{
+		APIKey:                "my_key",
+		APISecret:             "my_secret",
+		TLSHandshakeTimeout:   2 * time.Second,
+		ResponseHeaderTimeout: 500 * time.Millisecond,
+	}
+	client, err := getstream.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := getstream.New(&getstream.Config{APIKey: "my_key", APISecret: "my_secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport, ok := client.HTTP.Transport.(*http.Transport)
+	if !ok {
+		t.Fatal("expected an *http.Transport, got", client.HTTP.Transport)
+	}
+	if transport == other.HTTP.Transport || transport == getstream.GETSTREAM_TRANSPORT {
+		t.Error("expected every client to get its own transport")
+	}
+	if transport.TLSHandshakeTimeout != 2*time.Second || transport.ResponseHeaderTimeout != 500*time.Millisecond {
+		t.Error("expected the timeouts of the config, got", transport.TLSHandshakeTimeout, transport.ResponseHeaderTimeout)
+	}
+	if other.HTTP.Transport.(*http.Transport).TLSHandshakeTimeout != 10*time.Second {
+		t.Error("expected a default TLS handshake timeout of 10s")
+	}
+}
+
+func TestClientConnectionSettings(t *testing.T) {
+	proxyURL, _ := url.Parse("http://proxy.internal:3128")
+	tlsConfig := &tls.Config{ServerName: "api.getstream.io"}
+
+	client, err := getstream.New(&getstream.Config{
+		APIKey:              "my_key",
+		APISecret:           "my_secret",
+		MaxIdleConns:        200,
+		MaxIdleConnsPerHost: 100,
+		MaxConnsPerHost:     150,
+		IdleConnTimeout:     90 * time.Second,
+		DisableKeepAlives:   true,
+		EnableHTTP2:         true,
+		Proxy:               http.ProxyURL(proxyURL),
+		TLSConfig:           tlsConfig,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := client.HTTP.Transport.(*http.Transport)
+	if transport.MaxIdleConns != 200 || transport.MaxIdleConnsPerHost != 100 || transport.MaxConnsPerHost != 150 {
+		t.Error("unexpected pool settings:", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+	if transport.IdleConnTimeout != 90*time.Second || !transport.DisableKeepAlives || !transport.ForceAttemptHTTP2 {
+		t.Error("unexpected connection settings:", transport.IdleConnTimeout, transport.DisableKeepAlives, transport.ForceAttemptHTTP2)
+	}
+	if transport.TLSClientConfig != tlsConfig {
+		t.Error("expected the TLS config to be used")
+	}
+	req, _ := http.NewRequest("GET", "https://api.getstream.io/api/v1.0/", nil)
+	if proxy, _ := transport.Proxy(req); proxy == nil || proxy.String() != proxyURL.String() {
+		t.Error("expected the proxy to be used, got", proxy)
+	}
+
+	other, _ := getstream.New(&getstream.Config{APIKey: "my_key", APISecret: "my_secret"})
+	defaults := other.HTTP.Transport.(*http.Transport)
+	if defaults.MaxIdleConnsPerHost != 5 || defaults.Proxy != nil || defaults.TLSClientConfig != nil || defaults.ForceAttemptHTTP2 {
+		t.Error("expected the settings of a client not to leak into another")
+	}
+}
+
+// recordingTransport answers every request with an empty feed and records the requested urls
+type recordingTransport struct {
+	urls []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.urls = append(rt.urls, req.URL.String())
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"results":[]}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestClientCustomTransport(t *testing.T) {
+	transport := &recordingTransport{}
+	client, err := getstream.New(&getstream.Config{
+		APIKey:    "my_key",
+		APISecret: "my_secret",
+		Transport: transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.HTTP.Transport != transport {
+		t.Fatal("expected the custom transport to be used")
+	}
+
+	feed, _ := client.FlatFeed("flat", "bob")
+	_, err = feed.Activities(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.urls) != 1 || !strings.HasPrefix(transport.urls[0], "https://api.getstream.io/api/v1.0/feed/flat/bob/") {
+		t.Error("expected the request to go through the custom transport, got:", transport.urls)
+	}
+}
+
+func TestClientProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[]}`))
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	baseURL, _ := url.Parse("http://stream.invalid/api/v1.0/")
+	client, err := getstream.New(&getstream.Config{
+		APIKey:    "my_key",
+		APISecret: "my_secret",
+		BaseURL:   baseURL,
+		Proxy:     http.ProxyURL(proxyURL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, _ := client.FlatFeed("flat", "bob")
+	_, err = feed.Activities(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(proxied, "http://stream.invalid/api/v1.0/feed/flat/bob/") {
+		t.Error("expected the request to go through the proxy, got:", proxied)
+	}
+}
+
+func TestClientSubSecondTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL + "/api/v1.0/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []*getstream.Config{
+		{APIKey: "my_key", APISecret: "my_secret", BaseURL: baseURL, TimeoutDuration: 50 * time.Millisecond},
+		{APIKey: "my_key", APISecret: "my_secret", BaseURL: baseURL, ResponseHeaderTimeout: 50 * time.Millisecond},
+	} {
+		client, err := getstream.New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		feed, _ := client.FlatFeed("flat", "bob")
+
+		start := time.Now()
+		_, err = feed.Activities(nil)
+		if !getstream.IsTransportError(err) {
+			t.Error("expected a transport error, got:", err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Error("expected the request to time out after 50ms, took", elapsed)
+		}
+	}
+}
+
 func TestClientAbsoluteURL(t *testing.T) {
 	client, err := getstream.New(&getstream.Config{
 		APIKey:    "my_key",
@@ -463,3 +755,28 @@ func TestConvertUUIDToWord(t *testing.T) {
 		t.Fatal("ConvertUUIDToWord mismatch, expected '", expected, "', got:", foo)
 	}
 }
+
+func TestClientContextCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := newServerTestClient(t, server.URL)
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = feed.ActivitiesContext(ctx, &getstream.GetFlatFeedInput{})
+	if err == nil {
+		t.Fatal("Expected an error from a cancelled context")
+	}
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Fatal("Expected the context deadline to be exceeded, got:", ctx.Err())
+	}
+}
@@ -0,0 +1,38 @@
+package getstream
+
+import (
+	"net"
+	"net/http"
+)
+
+// GETSTREAM_TRANSPORT is no longer used by New, every Client gets its own transport built from its Config
+//
+// Deprecated: set the connection settings or the Transport of the Config instead
+var GETSTREAM_TRANSPORT = &http.Transport{
+	MaxIdleConns:        5,
+	MaxIdleConnsPerHost: 5,
+	IdleConnTimeout:     defaultIdleConnTimeout,
+	DisableKeepAlives:   false,
+}
+
+// newTransport builds the http transport of a Client from the connection settings of its Config
+func newTransport(cfg *Config) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   cfg.connectTimeout(),
+		KeepAlive: cfg.keepAlive(),
+	}
+
+	return &http.Transport{
+		Proxy:                 cfg.Proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       cfg.TLSConfig,
+		TLSHandshakeTimeout:   cfg.tlsHandshakeTimeout(),
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		MaxIdleConns:          cfg.maxIdleConns(),
+		MaxIdleConnsPerHost:   cfg.maxIdleConnsPerHost(),
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.idleConnTimeout(),
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+		ForceAttemptHTTP2:     cfg.EnableHTTP2,
+	}
+}
@@ -1,21 +1,77 @@
 package getstream
 
 import (
+	"crypto/tls"
+	"errors"
+	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
 // Config structure for configuration data
 type Config struct {
-	APIKey          string
-	APISecret       string
-	AppID           string
-	Location        string
-	TimeoutInt      int64
+	APIKey    string
+	APISecret string
+	AppID     string
+	Location  string
+	Version   string
+	Token     string
+
+	// SecondarySecrets are previous API secrets, tokens signed with them are still accepted by Client.Signer.VerifyToken
+	SecondarySecrets []string
+
+	// TimeoutInt is the time limit of a request in whole seconds, used when TimeoutDuration is not set
+	TimeoutInt int64
+	// TimeoutDuration is the time limit of a request, from dialing to reading the response body
+	// It defaults to TimeoutInt seconds, or 3 seconds; a negative value removes the limit, leaving deadlines
+	// to the context passed to the ...Context methods
 	TimeoutDuration time.Duration
-	Version         string
-	Token           string
-	BaseURL         *url.URL
+	// ConnectTimeout limits the time spent establishing a connection, 30 seconds when zero
+	ConnectTimeout time.Duration
+	// TLSHandshakeTimeout limits the time spent on the TLS handshake, 10 seconds when zero
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout limits the time spent waiting for the response headers once the request is sent,
+	// there is no limit besides TimeoutDuration when zero
+	ResponseHeaderTimeout time.Duration
+
+	// Transport sends the http requests of the client, replacing the transport New builds from the settings below
+	Transport http.RoundTripper
+	// MaxIdleConns caps the idle connections kept open across all hosts, 5 when zero
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps the idle connections kept open to a host, 5 when zero
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps the connections open to a host, including active ones, there is no limit when zero
+	// It requires Go 1.13 or later
+	MaxConnsPerHost int
+	// IdleConnTimeout closes connections left idle that long, 60 seconds when zero
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes, 30 seconds when zero; a negative value disables them
+	KeepAlive time.Duration
+	// DisableKeepAlives opens a new connection for every request
+	DisableKeepAlives bool
+	// EnableHTTP2 negotiates HTTP/2 on TLS connections, it requires Go 1.13 or later
+	EnableHTTP2 bool
+	// Proxy returns the proxy of a request, like http.ProxyFromEnvironment; requests are sent directly when it is nil
+	Proxy func(*http.Request) (*url.URL, error)
+	// TLSConfig configures TLS connections, it must not be modified once passed to New
+	TLSConfig *tls.Config
+
+	// BaseURL overrides the url of the API, which is otherwise derived from Location and Version
+	// Use it to point the client at a proxy, a private deployment or a local stand-in
+	BaseURL *url.URL
+
+	// HTTPClient sends the requests of the client as is, the timeouts and connection settings above are ignored when it is set
+	HTTPClient *http.Client
+
+	// Retry decides which failed requests are retried and how long to wait, nil attempts every request once
+	Retry *RetryPolicy
+	// WaitOnRateLimit makes requests wait for the reset of an exhausted rate limit instead of sending them
+	WaitOnRateLimit bool
+	// Instrumentation receives a RequestEvent for every request, nil reports nothing
+	Instrumentation Instrumentation
+	// Logger receives a line for every request, nil disables logging
+	Logger Logger
 }
 
 // SetAPIKey sets the API key for your GetStream.io account
@@ -55,6 +111,13 @@ func (c *Config) SetTimeout(timeout int64) time.Duration {
 	return c.TimeoutDuration
 }
 
+// SetTimeoutDuration sets the timeout for request timers, TimeoutInt is set to the whole seconds of it
+func (c *Config) SetTimeoutDuration(timeout time.Duration) time.Duration {
+	c.TimeoutInt = int64(timeout / time.Second)
+	c.TimeoutDuration = timeout
+	return c.TimeoutDuration
+}
+
 // SetVersion sets the version of the GetStream API to use
 // Currently, the only acceptable value is "v1.0"
 func (c *Config) SetVersion(version string) string {
@@ -73,3 +136,154 @@ func (c *Config) SetBaseURL(baseURL *url.URL) *url.URL {
 	c.BaseURL = baseURL
 	return c.BaseURL
 }
+
+// SetRetryPolicy sets the policy used to retry failed requests, nil disables retries
+func (c *Config) SetRetryPolicy(policy *RetryPolicy) *RetryPolicy {
+	c.Retry = policy
+	return c.Retry
+}
+
+// clone returns a copy of the Config sharing no retry policy, secrets or BaseURL with it
+func (c *Config) clone() Config {
+	cfg := *c
+	cfg.Retry = c.Retry.clone()
+	if c.SecondarySecrets != nil {
+		cfg.SecondarySecrets = append([]string(nil), c.SecondarySecrets...)
+	}
+	if c.BaseURL != nil {
+		baseURL := *c.BaseURL
+		cfg.BaseURL = &baseURL
+	}
+	return cfg
+}
+
+const (
+	defaultTimeout             = 3 * time.Second
+	defaultConnectTimeout      = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultIdleConnTimeout     = 60 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultMaxIdleConns        = 5
+)
+
+// timeout returns the time limit of a request, zero meaning no limit
+func (c *Config) timeout() time.Duration {
+	switch {
+	case c.TimeoutDuration < 0:
+		return 0
+	case c.TimeoutDuration > 0:
+		return c.TimeoutDuration
+	case c.TimeoutInt > 0:
+		return time.Duration(c.TimeoutInt) * time.Second
+	}
+	return defaultTimeout
+}
+
+// connectTimeout returns the time limit for establishing a connection
+func (c *Config) connectTimeout() time.Duration {
+	if c.ConnectTimeout > 0 {
+		return c.ConnectTimeout
+	}
+	return defaultConnectTimeout
+}
+
+// tlsHandshakeTimeout returns the time limit of the TLS handshake
+func (c *Config) tlsHandshakeTimeout() time.Duration {
+	if c.TLSHandshakeTimeout > 0 {
+		return c.TLSHandshakeTimeout
+	}
+	return defaultTLSHandshakeTimeout
+}
+
+// keepAlive returns the interval of TCP keep-alive probes, negative when they are disabled
+func (c *Config) keepAlive() time.Duration {
+	if c.KeepAlive != 0 {
+		return c.KeepAlive
+	}
+	return defaultKeepAlive
+}
+
+// idleConnTimeout returns how long idle connections are kept open
+func (c *Config) idleConnTimeout() time.Duration {
+	if c.IdleConnTimeout > 0 {
+		return c.IdleConnTimeout
+	}
+	return defaultIdleConnTimeout
+}
+
+// maxIdleConns returns the number of idle connections kept open across all hosts
+func (c *Config) maxIdleConns() int {
+	if c.MaxIdleConns > 0 {
+		return c.MaxIdleConns
+	}
+	return defaultMaxIdleConns
+}
+
+// maxIdleConnsPerHost returns the number of idle connections kept open to a host
+func (c *Config) maxIdleConnsPerHost() int {
+	if c.MaxIdleConnsPerHost > 0 {
+		return c.MaxIdleConnsPerHost
+	}
+	return defaultMaxIdleConns
+}
+
+// transport returns the http transport of a Client, Config.Transport when it is set
+func (c *Config) transport() http.RoundTripper {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	return newTransport(c)
+}
+
+// baseURL returns the url of the API to use
+// BaseURL is used when it is set, otherwise the url is derived from Location and Version
+func (c *Config) baseURL() (*url.URL, error) {
+	if c.BaseURL != nil {
+		return validateBaseURL(c.BaseURL, c.Version)
+	}
+	return locationBaseURL(c.Location, c.Version)
+}
+
+// locationBaseURL returns the url of the API for a Location
+// "localhost" and "qa" are served without SSL, localhost on port 8000
+func locationBaseURL(location string, version string) (*url.URL, error) {
+	host := "api"
+	port := ""
+	secure := "s"
+	if location != "" {
+		host = location + "-api"
+		if location == "qa" {
+			secure = ""
+		}
+		if location == "localhost" {
+			port = ":8000"
+			secure = ""
+		}
+	}
+
+	return url.Parse("http" + secure + "://" + host + ".getstream.io" + port + "/api/" + version + "/")
+}
+
+// validateBaseURL checks a BaseURL can be used to build request urls, and returns a normalized copy of it
+// A BaseURL without a path gets the default "/api/<version>/" path
+func validateBaseURL(baseURL *url.URL, version string) (*url.URL, error) {
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, errors.New("BaseURL scheme must be http or https, got " + baseURL.String())
+	}
+	if baseURL.Host == "" {
+		return nil, errors.New("BaseURL must have a host, got " + baseURL.String())
+	}
+	if baseURL.RawQuery != "" || baseURL.Fragment != "" {
+		return nil, errors.New("BaseURL must not have a query or fragment, got " + baseURL.String())
+	}
+
+	result := *baseURL
+	if result.Path == "" || result.Path == "/" {
+		result.Path = "/api/" + version + "/"
+	} else if !strings.HasSuffix(result.Path, "/") {
+		// request paths are resolved relative to the BaseURL, without the trailing slash the last segment would be dropped
+		result.Path += "/"
+	}
+	result.RawPath = ""
+	return &result, nil
+}
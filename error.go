@@ -1,11 +1,34 @@
 package getstream
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
 	"time"
 )
 
 // Credits to https://github.com/hyperworks/go-getstream for the error handling.
 
+// Sentinel errors to classify failures with errors.Is
+var (
+	// ErrNotFound : the feed, activity or follow relationship does not exist
+	ErrNotFound = errors.New("getstream: not found")
+	// ErrAuthFailed : the signature or token was rejected, or does not grant access to the resource
+	ErrAuthFailed = errors.New("getstream: authentication failed")
+	// ErrRateLimited : the rate limit of the endpoint was reached
+	ErrRateLimited = errors.New("getstream: rate limited")
+	// ErrInputInvalid : the request payload or params were rejected, see Error.ExceptionFields
+	ErrInputInvalid = errors.New("getstream: invalid input")
+	// ErrServerError : the API failed to handle the request
+	ErrServerError = errors.New("getstream: server error")
+	// ErrTransport : no response was received from the API
+	ErrTransport = errors.New("getstream: transport error")
+	// ErrTokenInvalid : a JWT passed to Signer.VerifyToken is malformed, badly signed, expired or not valid yet
+	ErrTokenInvalid = errors.New("getstream: invalid token")
+	// ErrTokenExpired : a JWT passed to Signer.VerifyToken has expired
+	ErrTokenExpired = errors.New("getstream: token expired")
+)
+
 // Error is a getstream error
 type Error struct {
 	Code       int `json:"code"`
@@ -14,10 +37,62 @@ type Error struct {
 	Detail      string `json:"detail"`
 	RawDuration string `json:"duration"`
 	Exception   string `json:"exception"`
+
+	// exceptionFields is kept as raw JSON so Error values stay comparable
+	exceptionFields string
 }
 
 var _ error = &Error{}
 
+// UnmarshalJSON is the custom unmarshal function for Errors
+// It keeps the exception_fields payload around for ExceptionFields()
+func (e *Error) UnmarshalJSON(b []byte) error {
+	type errorAlias Error
+	payload := struct {
+		*errorAlias
+		ExceptionFields json.RawMessage `json:"exception_fields"`
+	}{
+		errorAlias: (*errorAlias)(e),
+	}
+
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return err
+	}
+
+	e.exceptionFields = ""
+	if len(payload.ExceptionFields) > 0 && string(payload.ExceptionFields) != "null" {
+		e.exceptionFields = string(payload.ExceptionFields)
+	}
+	return nil
+}
+
+// ExceptionFields returns the field level validation errors of the request, keyed by field name
+// It is nil when the API did not report any
+func (e *Error) ExceptionFields() map[string][]string {
+	if e.exceptionFields == "" {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(e.exceptionFields), &raw); err != nil {
+		return nil
+	}
+
+	fields := make(map[string][]string, len(raw))
+	for field, value := range raw {
+		var messages []string
+		if err := json.Unmarshal(value, &messages); err != nil {
+			var message string
+			if err := json.Unmarshal(value, &message); err != nil {
+				message = string(value)
+			}
+			messages = []string{message}
+		}
+		fields[field] = messages
+	}
+	return fields
+}
+
 // Duration is the time it took for the request to be handled
 func (e *Error) Duration() time.Duration {
 	result, err := time.ParseDuration(e.RawDuration)
@@ -30,6 +105,9 @@ func (e *Error) Duration() time.Duration {
 
 func (e *Error) Error() string {
 	str := e.Exception
+	if str == "" {
+		str = http.StatusText(e.StatusCode)
+	}
 	if e.RawDuration != "" {
 		if duration := e.Duration(); duration > 0 {
 			str += " (" + duration.String() + ")"
@@ -42,3 +120,130 @@ func (e *Error) Error() string {
 
 	return str
 }
+
+// Is classifies the error for errors.Is, based on the HTTP status code
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrAuthFailed:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrInputInvalid:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrServerError:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// newResponseError builds the Error for a non 2xx response
+// Bodies which are not a JSON error, like HTML from a proxy or an empty body, still yield an Error
+func newResponseError(statusCode int, body []byte) *Error {
+	var respErr Error
+	if err := json.Unmarshal(body, &respErr); err != nil {
+		respErr = Error{
+			Exception: http.StatusText(statusCode),
+			Detail:    truncateBody(body),
+		}
+	}
+
+	if respErr.StatusCode == 0 {
+		respErr.StatusCode = statusCode
+	}
+	return &respErr
+}
+
+// truncateBody keeps error details readable when the API or a proxy returns a whole page
+func truncateBody(body []byte) string {
+	const max = 256
+	if len(body) > max {
+		return string(body[:max]) + "..."
+	}
+	return string(body)
+}
+
+// TransportError is returned when no response was received from the API
+// It wraps the error of the http client, so errors.Is(err, context.Canceled) keeps working
+type TransportError struct {
+	Err error
+}
+
+var _ error = &TransportError{}
+
+func (e *TransportError) Error() string {
+	return "transport error: " + e.Err.Error()
+}
+
+// Unwrap returns the error of the http client
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrTransport
+func (e *TransportError) Is(target error) bool {
+	return target == ErrTransport
+}
+
+// TokenError is returned when a JWT fails verification
+type TokenError struct {
+	Err     error
+	expired bool
+}
+
+var _ error = &TokenError{}
+
+func (e *TokenError) Error() string {
+	return "invalid token: " + e.Err.Error()
+}
+
+// Unwrap returns the reason the token was rejected
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrTokenInvalid, and ErrTokenExpired for expired tokens
+func (e *TokenError) Is(target error) bool {
+	return target == ErrTokenInvalid || (target == ErrTokenExpired && e.expired)
+}
+
+// IsNotFound reports if err means the requested resource does not exist
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsAuthFailed reports if err means the request was not authorized
+func IsAuthFailed(err error) bool {
+	return errors.Is(err, ErrAuthFailed)
+}
+
+// IsRateLimited reports if err means the rate limit was reached
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsInputInvalid reports if err means the request was rejected as invalid
+func IsInputInvalid(err error) bool {
+	return errors.Is(err, ErrInputInvalid)
+}
+
+// IsServerError reports if err means the API failed to handle the request
+func IsServerError(err error) bool {
+	return errors.Is(err, ErrServerError)
+}
+
+// IsTransportError reports if err means no response was received
+func IsTransportError(err error) bool {
+	return errors.Is(err, ErrTransport)
+}
+
+// IsTokenInvalid reports if err means a JWT failed verification
+func IsTokenInvalid(err error) bool {
+	return errors.Is(err, ErrTokenInvalid)
+}
+
+// IsTokenExpired reports if err means a JWT has expired
+func IsTokenExpired(err error) bool {
+	return errors.Is(err, ErrTokenExpired)
+}
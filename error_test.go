@@ -2,6 +2,9 @@ package getstream_test
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -54,3 +57,108 @@ func TestErrorBadDuration(t *testing.T) {
 	}
 
 }
+
+func TestErrorExceptionFields(t *testing.T) {
+	errorResponse := `{"code": 4, "detail": "Errors for fields 'actor'", "exception": "InputException", "status_code": 400, "exception_fields": {"actor": ["This field is required."], "verb": "too long"}}`
+
+	var getStreamError getstream.Error
+	err := json.Unmarshal([]byte(errorResponse), &getStreamError)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := getStreamError.ExceptionFields()
+	if len(fields["actor"]) != 1 || fields["actor"][0] != "This field is required." {
+		t.Error("Unexpected actor field errors:", fields["actor"])
+	}
+	if len(fields["verb"]) != 1 || fields["verb"][0] != "too long" {
+		t.Error("Unexpected verb field errors:", fields["verb"])
+	}
+
+	if !getstream.IsInputInvalid(&getStreamError) {
+		t.Error("Expected a 400 to be an invalid input error")
+	}
+	if getstream.IsNotFound(&getStreamError) || getstream.IsServerError(&getStreamError) {
+		t.Error("Expected a 400 not to match other error kinds")
+	}
+
+	if (&getstream.Error{}).ExceptionFields() != nil {
+		t.Error("Expected no field errors")
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	cases := map[int]error{
+		404: getstream.ErrNotFound,
+		401: getstream.ErrAuthFailed,
+		403: getstream.ErrAuthFailed,
+		429: getstream.ErrRateLimited,
+		400: getstream.ErrInputInvalid,
+		500: getstream.ErrServerError,
+		503: getstream.ErrServerError,
+	}
+
+	for statusCode, target := range cases {
+		var err error = &getstream.Error{StatusCode: statusCode}
+		if !errors.Is(err, target) {
+			t.Errorf("%d: expected errors.Is to match %s", statusCode, target)
+		}
+		if errors.Is(err, getstream.ErrTransport) {
+			t.Errorf("%d: expected an API error not to be a transport error", statusCode)
+		}
+	}
+}
+
+func TestErrorNonJSONBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html><body>502 Bad Gateway</body></html>"))
+	}))
+	defer server.Close()
+
+	client := newServerTestClient(t, server.URL)
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+
+	var getStreamError *getstream.Error
+	if !errors.As(err, &getStreamError) {
+		t.Fatal("Expected a *getstream.Error, got:", err)
+	}
+	if getStreamError.StatusCode != http.StatusBadGateway {
+		t.Error("Expected the HTTP status to be kept, got:", getStreamError.StatusCode)
+	}
+	if getStreamError.Error() != "Bad Gateway: <html><body>502 Bad Gateway</body></html>" {
+		t.Error("Unexpected error message:", getStreamError.Error())
+	}
+	if !getstream.IsServerError(err) {
+		t.Error("Expected a 502 to be a server error")
+	}
+}
+
+func TestErrorTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	client := newServerTestClient(t, serverURL)
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if !getstream.IsTransportError(err) {
+		t.Fatal("Expected a transport error, got:", err)
+	}
+
+	var getStreamError *getstream.Error
+	if errors.As(err, &getStreamError) {
+		t.Fatal("Expected a transport error not to carry an API error")
+	}
+}
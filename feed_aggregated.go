@@ -1,16 +1,10 @@
 package getstream
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"regexp"
-	"strings"
 )
 
-type postAggregatedFeedOutputActivities struct {
-	Activities []*Activity `json:"activities"`
-}
-
 // GetAggregatedFeedInput is used to Get a list of Activities from a AggregatedFeed
 type GetAggregatedFeedInput struct {
 	Limit  int `json:"limit,omitempty"`
@@ -24,20 +18,32 @@ type GetAggregatedFeedInput struct {
 	Ranking string `json:"ranking,omitempty"`
 }
 
+// Params returns the query params for the input
+func (i *GetAggregatedFeedInput) Params() (params map[string]string) {
+	if i == nil {
+		return map[string]string{}
+	}
+	return readParams(i.Limit, i.Offset, i.IDGTE, i.IDGT, i.IDLTE, i.IDLT, i.Ranking)
+}
+
+// AggregatedFeedGroup is a group of Activities returned by a AggregatedFeed
+// It is an alias so the Results of GetAggregatedFeedOutput keep their original type
+type AggregatedFeedGroup = struct {
+	Activities    []*Activity
+	ActivityCount int
+	ActorCount    int
+	CreatedAt     string
+	Group         string
+	ID            string
+	UpdatedAt     string
+	Verb          string
+}
+
 // GetAggregatedFeedOutput is the response from a AggregatedFeed Activities Get Request
 type GetAggregatedFeedOutput struct {
 	Duration string
 	Next     string
-	Results  []*struct {
-		Activities    []*Activity
-		ActivityCount int
-		ActorCount    int
-		CreatedAt     string
-		Group         string
-		ID            string
-		UpdatedAt     string
-		Verb          string
-	}
+	Results  []*AggregatedFeedGroup
 }
 
 type getAggregatedFeedOutput struct {
@@ -53,29 +59,11 @@ func (a getAggregatedFeedOutput) output() *GetAggregatedFeedOutput {
 		Next:     a.Next,
 	}
 
-	var results []*struct {
-		Activities    []*Activity
-		ActivityCount int
-		ActorCount    int
-		CreatedAt     string
-		Group         string
-		ID            string
-		UpdatedAt     string
-		Verb          string
-	}
+	var results []*AggregatedFeedGroup
 
 	for _, result := range a.Results {
 
-		outputResult := struct {
-			Activities    []*Activity
-			ActivityCount int
-			ActorCount    int
-			CreatedAt     string
-			Group         string
-			ID            string
-			UpdatedAt     string
-			Verb          string
-		}{
+		outputResult := AggregatedFeedGroup{
 			ActivityCount: result.ActivityCount,
 			ActorCount:    result.ActorCount,
 			CreatedAt:     result.CreatedAt,
@@ -108,28 +96,6 @@ type getAggregatedFeedOutputResult struct {
 	Verb          string      `json:"verb"`
 }
 
-type getAggregatedFeedFollowersInput struct {
-	Limit int `json:"limit"`
-	Skip  int `json:"offset"`
-}
-
-type getAggregatedFeedFollowersOutput struct {
-	Duration string                                    `json:"duration"`
-	Results  []*getAggregatedFeedFollowersOutputResult `json:"results"`
-}
-
-type getAggregatedFeedFollowersOutputResult struct {
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	FeedID    string `json:"feed_id"`
-	TargetID  string `json:"target_id"`
-}
-
-type postAggregatedFeedFollowingInput struct {
-	Target            string `json:"target"`
-	ActivityCopyLimit int    `json:"activity_copy_limit"`
-}
-
 // AggregatedFeed is a getstream AggregatedFeed
 // Use it to for CRUD on AggregatedFeed Groups
 type AggregatedFeed struct {
@@ -139,28 +105,34 @@ type AggregatedFeed struct {
 	token    string
 }
 
+// core returns the shared feed implementation bound to this AggregatedFeed
+func (f *AggregatedFeed) core() *feedCore {
+	return &feedCore{
+		Client:   f.Client,
+		FeedSlug: f.FeedSlug,
+		UserID:   f.UserID,
+		token:    &f.token,
+	}
+}
+
 // Signature is used to sign Requests : "FeedSlugUserID Token"
 func (f *AggregatedFeed) Signature() string {
-	if f.Token() == "" {
-		return f.FeedIDWithoutColon()
-	}
-	return f.FeedIDWithoutColon() + " " + f.Token()
+	return f.core().Signature()
 }
 
 // FeedID is the combo if the FeedSlug and UserID : "FeedSlug:UserID"
 func (f *AggregatedFeed) FeedID() FeedID {
-	return FeedID(f.FeedSlug + ":" + f.UserID)
+	return f.core().FeedID()
 }
 
+// FeedIDWithoutColon is the combo if the FeedSlug and UserID : "FeedSlugUserID"
 func (f *AggregatedFeed) FeedIDWithoutColon() string {
-	return f.FeedSlug + f.UserID
+	return f.core().FeedIDWithoutColon()
 }
 
 // SignFeed sets the token on a Feed
 func (f *AggregatedFeed) SignFeed(signer *Signer) {
-	if f.Client.Signer != nil {
-		f.token = signer.GenerateToken(f.FeedIDWithoutColon())
-	}
+	f.core().SignFeed(signer)
 }
 
 // Token returns the token of a Feed
@@ -170,78 +142,37 @@ func (f *AggregatedFeed) Token() string {
 
 // GenerateToken returns a new Token for a Feed without setting it to the Feed
 func (f *AggregatedFeed) GenerateToken(signer *Signer) string {
-	if f.Client.Signer != nil {
-		return signer.GenerateToken(f.FeedSlug + f.UserID)
-	}
-	return ""
+	return f.core().GenerateToken(signer)
 }
 
 // AddActivity is used to add an Activity to a AggregatedFeed
 func (f *AggregatedFeed) AddActivity(activity *Activity) (*Activity, error) {
+	return f.AddActivityContext(context.Background(), activity)
+}
 
-	payload, err := json.Marshal(activity)
-	if err != nil {
-		return nil, err
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	resultBytes, err := f.Client.post(f, endpoint, payload, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	output := &Activity{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	return output, err
+// AddActivityContext is the context.Context aware version of AddActivity
+func (f *AggregatedFeed) AddActivityContext(ctx context.Context, activity *Activity) (*Activity, error) {
+	return f.core().addActivity(ctx, activity)
 }
 
-// AddActivities is used to add multiple Activities to a NotificationFeed
+// AddActivities is used to add multiple Activities to a AggregatedFeed
 func (f *AggregatedFeed) AddActivities(activities []*Activity) ([]*Activity, error) {
+	return f.AddActivitiesContext(context.Background(), activities)
+}
 
-	payload, err := json.Marshal(map[string][]*Activity{
-		"activities": activities,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	resultBytes, err := f.Client.post(f, endpoint, payload, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	output := &postAggregatedFeedOutputActivities{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	return output.Activities, err
+// AddActivitiesContext is the context.Context aware version of AddActivities
+func (f *AggregatedFeed) AddActivitiesContext(ctx context.Context, activities []*Activity) ([]*Activity, error) {
+	return f.core().addActivities(ctx, activities)
 }
 
-// Activities returns a list of Activities for a NotificationFeedGroup
+// Activities returns a list of Activities for a AggregatedFeedGroup
 func (f *AggregatedFeed) Activities(input *GetAggregatedFeedInput) (*GetAggregatedFeedOutput, error) {
+	return f.ActivitiesContext(context.Background(), input)
+}
 
-	var payload []byte
-	var err error
-
-	if input != nil {
-		payload, err = json.Marshal(input)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	result, err := f.Client.get(f, endpoint, payload, nil)
+// ActivitiesContext is the context.Context aware version of Activities
+func (f *AggregatedFeed) ActivitiesContext(ctx context.Context, input *GetAggregatedFeedInput) (*GetAggregatedFeedOutput, error) {
+	result, err := f.core().activities(ctx, input.Params())
 	if err != nil {
 		return nil, err
 	}
@@ -255,164 +186,107 @@ func (f *AggregatedFeed) Activities(input *GetAggregatedFeedInput) (*GetAggregat
 	return output.output(), err
 }
 
-// RemoveActivity removes an Activity from a NotificationFeedGroup
+// RemoveActivity removes an Activity from a AggregatedFeedGroup
 func (f *AggregatedFeed) RemoveActivity(input *Activity) error {
+	return f.RemoveActivityContext(context.Background(), input)
+}
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + input.ID + "/"
-
-	return f.Client.del(f, endpoint, nil, nil)
+// RemoveActivityContext is the context.Context aware version of RemoveActivity
+func (f *AggregatedFeed) RemoveActivityContext(ctx context.Context, input *Activity) error {
+	return f.core().removeActivity(ctx, input)
 }
 
-// RemoveActivityByForeignID removes an Activity from a NotificationFeedGroup by ForeignID
+// RemoveActivityByForeignID removes an Activity from a AggregatedFeedGroup by ForeignID
 func (f *AggregatedFeed) RemoveActivityByForeignID(input *Activity) error {
+	return f.RemoveActivityByForeignIDContext(context.Background(), input)
+}
 
-	if input.ForeignID == "" {
-		return errors.New("no ForeignID")
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + input.ForeignID + "/"
-
-	return f.Client.del(f, endpoint, nil, map[string]string{
-		"foreign_id": "1",
-	})
+// RemoveActivityByForeignIDContext is the context.Context aware version of RemoveActivityByForeignID
+func (f *AggregatedFeed) RemoveActivityByForeignIDContext(ctx context.Context, input *Activity) error {
+	return f.core().removeActivityByForeignID(ctx, input)
 }
 
 // FollowFeedWithCopyLimit sets a Feed to follow another target Feed
 // CopyLimit is the maximum number of Activities to Copy from History
 func (f *AggregatedFeed) FollowFeedWithCopyLimit(target *FlatFeed, copyLimit int) error {
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/"
-
-	input := postAggregatedFeedFollowingInput{
-		Target:            target.FeedID().Value(),
-		ActivityCopyLimit: copyLimit,
-	}
-
-	payload, err := json.Marshal(input)
-	if err != nil {
-		return err
-	}
-
-	_, err = f.Client.post(f, endpoint, payload, nil)
-	return err
+	return f.FollowFeedWithCopyLimitContext(context.Background(), target, copyLimit)
+}
 
+// FollowFeedWithCopyLimitContext is the context.Context aware version of FollowFeedWithCopyLimit
+func (f *AggregatedFeed) FollowFeedWithCopyLimitContext(ctx context.Context, target *FlatFeed, copyLimit int) error {
+	return f.core().follow(ctx, target, copyLimit)
 }
 
 // Unfollow is used to Unfollow a target Feed
 func (f *AggregatedFeed) Unfollow(target *FlatFeed) error {
+	return f.UnfollowContext(context.Background(), target)
+}
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/" + target.FeedID().Value() + "/"
-
-	return f.Client.del(f, endpoint, nil, nil)
-
+// UnfollowContext is the context.Context aware version of Unfollow
+func (f *AggregatedFeed) UnfollowContext(ctx context.Context, target *FlatFeed) error {
+	return f.core().unfollow(ctx, target, false)
 }
 
 // UnfollowKeepingHistory is used to Unfollow a target Feed while keeping the History
 // this means that Activities already visibile will remain
 func (f *AggregatedFeed) UnfollowKeepingHistory(target *FlatFeed) error {
+	return f.UnfollowKeepingHistoryContext(context.Background(), target)
+}
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/" + target.FeedID().Value() + "/"
-
-	payload, err := json.Marshal(map[string]string{
-		"keep_history": "1",
-	})
-	if err != nil {
-		return err
-	}
-
-	return f.Client.del(f, endpoint, payload, nil)
-
+// UnfollowKeepingHistoryContext is the context.Context aware version of UnfollowKeepingHistory
+func (f *AggregatedFeed) UnfollowKeepingHistoryContext(ctx context.Context, target *FlatFeed) error {
+	return f.core().unfollow(ctx, target, true)
 }
 
 // FollowersWithLimitAndSkip returns a list of GeneralFeed following the current AggregatedFeed
 func (f *AggregatedFeed) FollowersWithLimitAndSkip(limit int, skip int) ([]*GeneralFeed, error) {
-	var err error
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "followers" + "/"
-
-	payload, err := json.Marshal(&getFlatFeedFollowersInput{
-		Limit: limit,
-		Skip:  skip,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	resultBytes, err := f.Client.get(f, endpoint, payload, nil)
-
-	output := &getAggregatedFeedFollowersOutput{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	var outputFeeds []*GeneralFeed
-	for _, result := range output.Results {
-
-		feed := GeneralFeed{}
-
-		var match bool
-		match, err = regexp.MatchString(`^.*?:.*?$`, result.FeedID)
-		if err != nil {
-			continue
-		}
-
-		if match {
-			firstSplit := strings.Split(result.FeedID, ":")
-
-			feed.FeedSlug = firstSplit[0]
-			feed.UserID = firstSplit[1]
-		}
-
-		outputFeeds = append(outputFeeds, &feed)
-	}
+	return f.FollowersWithLimitAndSkipContext(context.Background(), limit, skip)
+}
 
-	return outputFeeds, err
+// FollowersWithLimitAndSkipContext is the context.Context aware version of FollowersWithLimitAndSkip
+func (f *AggregatedFeed) FollowersWithLimitAndSkipContext(ctx context.Context, limit int, skip int) ([]*GeneralFeed, error) {
+	return f.core().followers(ctx, limit, skip)
 }
 
-// FollowingWithLimitAndSkip returns a list of GeneralFeed followed by the current FlatFeed
+// FollowingWithLimitAndSkip returns a list of GeneralFeed followed by the current AggregatedFeed
+// TODO: need to support filters
 func (f *AggregatedFeed) FollowingWithLimitAndSkip(limit int, skip int) ([]*GeneralFeed, error) {
+	return f.FollowingWithLimitAndSkipContext(context.Background(), limit, skip)
+}
 
-	var err error
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/"
-
-	payload, err := json.Marshal(&getAggregatedFeedFollowersInput{
-		Limit: limit,
-		Skip:  skip,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	resultBytes, err := f.Client.get(f, endpoint, payload, nil)
-
-	output := &getAggregatedFeedFollowersOutput{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	var outputFeeds []*GeneralFeed
-	for _, result := range output.Results {
-
-		feed := GeneralFeed{}
+// FollowingWithLimitAndSkipContext is the context.Context aware version of FollowingWithLimitAndSkip
+func (f *AggregatedFeed) FollowingWithLimitAndSkipContext(ctx context.Context, limit int, skip int) ([]*GeneralFeed, error) {
+	return f.core().following(ctx, limit, skip)
+}
 
-		var match bool
-		match, err = regexp.MatchString(`^.*?:.*?$`, result.FeedID)
-		if err != nil {
-			continue
-		}
+// IterateFollowers returns an iterator over the feeds following the current AggregatedFeed
+// pageSize is the number of feeds read per request, 0 uses the API default
+func (f *AggregatedFeed) IterateFollowers(pageSize int) *FollowIterator {
+	return f.IterateFollowersContext(context.Background(), pageSize)
+}
 
-		if match {
-			firstSplit := strings.Split(result.TargetID, ":")
+// IterateFollowersContext is the context.Context aware version of IterateFollowers
+func (f *AggregatedFeed) IterateFollowersContext(ctx context.Context, pageSize int) *FollowIterator {
+	return f.core().iterateFollows(ctx, "followers", pageSize)
+}
 
-			feed.FeedSlug = firstSplit[0]
-			feed.UserID = firstSplit[1]
-		}
+// IterateFollowing returns an iterator over the feeds followed by the current AggregatedFeed
+// pageSize is the number of feeds read per request, 0 uses the API default
+func (f *AggregatedFeed) IterateFollowing(pageSize int) *FollowIterator {
+	return f.IterateFollowingContext(context.Background(), pageSize)
+}
 
-		outputFeeds = append(outputFeeds, &feed)
-	}
+// IterateFollowingContext is the context.Context aware version of IterateFollowing
+func (f *AggregatedFeed) IterateFollowingContext(ctx context.Context, pageSize int) *FollowIterator {
+	return f.core().iterateFollows(ctx, "following", pageSize)
+}
 
-	return outputFeeds, err
+// FollowStats returns the number of feeds following the current AggregatedFeed and the number of feeds it follows
+func (f *AggregatedFeed) FollowStats() (*FollowStats, error) {
+	return f.FollowStatsContext(context.Background())
+}
 
+// FollowStatsContext is the context.Context aware version of FollowStats
+func (f *AggregatedFeed) FollowStatsContext(ctx context.Context) (*FollowStats, error) {
+	return f.core().followStats(ctx)
 }
@@ -428,7 +428,7 @@ func TestAggregatedActivityMetaData(t *testing.T) {
 	if resultActivity.Target != activity.Target {
 		t.Error(activity.Target, resultActivity.Target)
 	}
-	if resultActivity.TimeStamp.Format("2006-01-02T15:04:05.999999") != activity.TimeStamp.Format("2006-01-02T15:04:05.999999") {
+	if !resultActivity.TimeStamp.Equal(activity.TimeStamp.Truncate(time.Microsecond)) {
 		t.Error(activity.TimeStamp, resultActivity.TimeStamp)
 	}
 	if resultActivity.MetaData["meta"] != activity.MetaData["meta"] {
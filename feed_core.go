@@ -0,0 +1,370 @@
+package getstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// feedCore implements everything FlatFeed, AggregatedFeed, NotificationFeed and GeneralFeed have in common
+// The feed types delegate to it, so an endpoint added or fixed here behaves identically across feed kinds
+type feedCore struct {
+	Client   *Client
+	FeedSlug string
+	UserID   string
+	token    *string // points to the token of the feed this core belongs to
+}
+
+var _ Feed = &feedCore{}
+
+// Signature is used to sign Requests : "FeedSlugUserID Token"
+func (f *feedCore) Signature() string {
+	if f.Token() == "" {
+		return f.FeedIDWithoutColon()
+	}
+	return f.FeedIDWithoutColon() + " " + f.Token()
+}
+
+// FeedID is the combo if the FeedSlug and UserID : "FeedSlug:UserID"
+func (f *feedCore) FeedID() FeedID {
+	return FeedID(f.FeedSlug + ":" + f.UserID)
+}
+
+// FeedIDWithoutColon is the combo if the FeedSlug and UserID : "FeedSlugUserID"
+func (f *feedCore) FeedIDWithoutColon() string {
+	return f.FeedSlug + f.UserID
+}
+
+// SignFeed sets the token on a Feed
+func (f *feedCore) SignFeed(signer *Signer) {
+	if f.Client.Signer != nil {
+		*f.token = signer.GenerateToken(f.FeedIDWithoutColon())
+	}
+}
+
+// Token returns the token of a Feed
+func (f *feedCore) Token() string {
+	return *f.token
+}
+
+// GenerateToken returns a new Token for a Feed without setting it to the Feed
+func (f *feedCore) GenerateToken(signer *Signer) string {
+	if f.Client.Signer != nil {
+		return signer.GenerateToken(f.FeedIDWithoutColon())
+	}
+	return ""
+}
+
+// endpoint returns the path of the feed, followed by the given path segments
+func (f *feedCore) endpoint(segments ...string) string {
+	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
+	for _, segment := range segments {
+		endpoint += segment + "/"
+	}
+	return endpoint
+}
+
+type postFeedOutputActivities struct {
+	Activities []*Activity `json:"activities"`
+}
+
+// addActivity adds an Activity to the feed
+// the API assigns activity ids, so an ID set on the activity is not sent along
+func (f *feedCore) addActivity(ctx context.Context, activity *Activity) (*Activity, error) {
+	input := *activity
+	input.ID = ""
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if activity.ForeignID != "" {
+		// the API de-duplicates activities by ForeignID, so the write is safe to retry
+		ctx = withIdempotentWrite(ctx)
+	}
+
+	resultBytes, err := f.Client.post(ctx, f, f.endpoint(), payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &Activity{}
+	err = json.Unmarshal(resultBytes, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return output, err
+}
+
+// addActivities adds multiple Activities to the feed in a single request
+func (f *feedCore) addActivities(ctx context.Context, activities []*Activity) ([]*Activity, error) {
+	inputs := make([]Activity, len(activities))
+	for i, activity := range activities {
+		inputs[i] = *activity
+		inputs[i].ID = ""
+	}
+
+	payload, err := json.Marshal(map[string][]Activity{
+		"activities": inputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if allHaveForeignID(activities) {
+		// the API de-duplicates activities by ForeignID, so the write is safe to retry
+		ctx = withIdempotentWrite(ctx)
+	}
+
+	resultBytes, err := f.Client.post(ctx, f, f.endpoint(), payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &postFeedOutputActivities{}
+	err = json.Unmarshal(resultBytes, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Activities, err
+}
+
+// activities reads the feed, the caller decodes the response for its feed type
+func (f *feedCore) activities(ctx context.Context, params map[string]string) ([]byte, error) {
+	return f.Client.get(ctx, f, f.endpoint(), nil, params)
+}
+
+// removeActivity removes an Activity from the feed by ID
+func (f *feedCore) removeActivity(ctx context.Context, input *Activity) error {
+	return f.Client.del(ctx, f, f.endpoint(input.ID), nil, nil)
+}
+
+// removeActivityByForeignID removes an Activity from the feed by ForeignID
+func (f *feedCore) removeActivityByForeignID(ctx context.Context, input *Activity) error {
+	if input.ForeignID == "" {
+		return errors.New("no ForeignID")
+	}
+
+	return f.Client.del(ctx, f, f.endpoint(input.ForeignID), nil, map[string]string{
+		"foreign_id": "1",
+	})
+}
+
+type postFeedFollowingInput struct {
+	Target            string `json:"target"`
+	ActivityCopyLimit int    `json:"activity_copy_limit"`
+}
+
+// follow makes the feed follow a target feed
+// copyLimit is the maximum number of Activities to Copy from History
+func (f *feedCore) follow(ctx context.Context, target Feed, copyLimit int) error {
+	payload, err := json.Marshal(postFeedFollowingInput{
+		Target:            target.FeedID().Value(),
+		ActivityCopyLimit: copyLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Client.post(ctx, f, f.endpoint("following"), payload, nil)
+	return err
+}
+
+// unfollow makes the feed stop following a target feed
+// with keepHistory the Activities already copied from the target remain in the feed
+func (f *feedCore) unfollow(ctx context.Context, target Feed, keepHistory bool) error {
+	var params map[string]string
+	if keepHistory {
+		params = map[string]string{
+			"keep_history": "1",
+		}
+	}
+
+	return f.Client.del(ctx, f, f.endpoint("following", target.FeedID().Value()), nil, params)
+}
+
+type getFollowOutput struct {
+	Duration string                 `json:"duration"`
+	Results  []*getFollowOutputItem `json:"results"`
+}
+
+type getFollowOutputItem struct {
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	FeedID    string `json:"feed_id"`
+	TargetID  string `json:"target_id"`
+}
+
+// followers returns the feeds following this feed
+func (f *feedCore) followers(ctx context.Context, limit int, skip int) ([]*GeneralFeed, error) {
+	output, err := f.follows(ctx, "followers", limit, skip)
+	if err != nil {
+		return nil, err
+	}
+	return f.followFeeds(output, "followers"), nil
+}
+
+// following returns the feeds this feed follows
+func (f *feedCore) following(ctx context.Context, limit int, skip int) ([]*GeneralFeed, error) {
+	output, err := f.follows(ctx, "following", limit, skip)
+	if err != nil {
+		return nil, err
+	}
+	return f.followFeeds(output, "following"), nil
+}
+
+// followFeeds returns the feeds on the other end of the follow relationships in a page
+// for followers that is the following feed, for following the followed target
+func (f *feedCore) followFeeds(output *getFollowOutput, endpoint string) []*GeneralFeed {
+	var feeds []*GeneralFeed
+	for _, result := range output.Results {
+		feedID := result.TargetID
+		if endpoint == "followers" {
+			feedID = result.FeedID
+		}
+		if feed := f.generalFeed(feedID); feed != nil {
+			feeds = append(feeds, feed)
+		}
+	}
+	return feeds
+}
+
+// defaultFollowPageSize is the page size used by follow iterators when none is given, it matches the API default
+const defaultFollowPageSize = 25
+
+// iterateFollows returns an iterator over the followers or following of the feed
+// a page shorter than pageSize is the last one
+func (f *feedCore) iterateFollows(ctx context.Context, endpoint string, pageSize int) *FollowIterator {
+	if pageSize <= 0 {
+		pageSize = defaultFollowPageSize
+	}
+
+	it := &FollowIterator{}
+	it.pages = newPageIterator(ctx, pageCursor{}, func(ctx context.Context, cursor pageCursor) (int, pageCursor, bool, error) {
+		output, err := f.follows(ctx, endpoint, pageSize, cursor.offset)
+		if err != nil {
+			return 0, cursor, false, err
+		}
+		it.page = f.followFeeds(output, endpoint)
+
+		// malformed feed ids are skipped, so paging goes by the number of results the API returned
+		next := pageCursor{offset: cursor.offset + len(output.Results)}
+		if len(it.page) == 0 && len(output.Results) == pageSize {
+			// a full page of malformed ids, move on to the next one
+			return 0, next, true, nil
+		}
+		return len(it.page), next, len(output.Results) == pageSize, nil
+	})
+	return it
+}
+
+// follows reads one page of the followers or following endpoint
+func (f *feedCore) follows(ctx context.Context, endpoint string, limit int, skip int) (*getFollowOutput, error) {
+	params := map[string]string{
+		"limit":  strconv.Itoa(limit),
+		"offset": strconv.Itoa(skip),
+	}
+
+	resultBytes, err := f.Client.get(ctx, f, f.endpoint(endpoint), nil, params)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &getFollowOutput{}
+	err = json.Unmarshal(resultBytes, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// FollowStats are the follower and following counts of a feed
+type FollowStats struct {
+	Followers int
+	Following int
+}
+
+type getFollowStatsOutput struct {
+	Duration string `json:"duration"`
+	Results  struct {
+		Followers getFollowStatsOutputCount `json:"followers"`
+		Following getFollowStatsOutputCount `json:"following"`
+	} `json:"results"`
+}
+
+type getFollowStatsOutputCount struct {
+	Feed  string `json:"feed"`
+	Count int    `json:"count"`
+}
+
+// followStats returns the number of feeds following this feed and the number of feeds it follows
+func (f *feedCore) followStats(ctx context.Context) (*FollowStats, error) {
+	params := map[string]string{
+		"followers": f.FeedID().Value(),
+		"following": f.FeedID().Value(),
+	}
+
+	resultBytes, err := f.Client.get(ctx, f, "stats/follow/", nil, params)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &getFollowStatsOutput{}
+	err = json.Unmarshal(resultBytes, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FollowStats{
+		Followers: output.Results.Followers.Count,
+		Following: output.Results.Following.Count,
+	}, nil
+}
+
+// generalFeed builds a GeneralFeed from a "FeedSlug:UserID" string, nil if it is malformed
+func (f *feedCore) generalFeed(feedID string) *GeneralFeed {
+	parts := strings.SplitN(feedID, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil
+	}
+
+	return &GeneralFeed{
+		Client:   f.Client,
+		FeedSlug: parts[0],
+		UserID:   parts[1],
+	}
+}
+
+// readParams builds the query params shared by the Activities inputs of every feed type
+func readParams(limit int, offset int, idGTE string, idGT string, idLTE string, idLT string, ranking string) map[string]string {
+	params := make(map[string]string)
+
+	if limit != 0 {
+		params["limit"] = fmt.Sprintf("%d", limit)
+	}
+	if offset != 0 {
+		params["offset"] = fmt.Sprintf("%d", offset)
+	}
+	if idGTE != "" {
+		params["id_gte"] = idGTE
+	}
+	if idGT != "" {
+		params["id_gt"] = idGT
+	}
+	if idLTE != "" {
+		params["id_lte"] = idLTE
+	}
+	if idLT != "" {
+		params["id_lt"] = idLT
+	}
+	if ranking != "" {
+		params["ranking"] = ranking
+	}
+	return params
+}
@@ -0,0 +1,177 @@
+package getstream_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+// newFeedCoreTestClient returns a client pointing to a server which records the last request
+func newFeedCoreTestClient(t *testing.T, body string) (*getstream.Client, *http.Request, func()) {
+	last := &http.Request{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*last = *r
+		w.Write([]byte(body))
+	}))
+
+	client := newServerTestClient(t, server.URL)
+
+	return client, last, server.Close
+}
+
+func TestFeedCoreFollowersAcrossFeedTypes(t *testing.T) {
+	body := `{"duration": "1ms", "results": [
+		{"feed_id": "timeline:alice", "target_id": "flat:bob"},
+		{"feed_id": "malformed", "target_id": "malformed"},
+		{"feed_id": "timeline:carol", "target_id": "flat:bob"}
+	]}`
+	client, last, done := newFeedCoreTestClient(t, body)
+	defer done()
+
+	flat, _ := client.FlatFeed("flat", "bob")
+	aggregated, _ := client.AggregatedFeed("flat", "bob")
+	notification, _ := client.NotificationFeed("flat", "bob")
+
+	for name, followers := range map[string]func(int, int) ([]*getstream.GeneralFeed, error){
+		"flat":         flat.FollowersWithLimitAndSkip,
+		"aggregated":   aggregated.FollowersWithLimitAndSkip,
+		"notification": notification.FollowersWithLimitAndSkip,
+	} {
+		feeds, err := followers(10, 20)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		if last.URL.Path != "/api/v1.0/feed/flat/bob/followers/" {
+			t.Error(name, "unexpected path:", last.URL.Path)
+		}
+		if last.URL.Query().Get("limit") != "10" || last.URL.Query().Get("offset") != "20" {
+			t.Error(name, "expected limit and offset as query params, got:", last.URL.RawQuery)
+		}
+
+		if len(feeds) != 2 {
+			t.Fatal(name, "expected the malformed follower to be skipped, got:", len(feeds))
+		}
+		if feeds[0].FeedID() != "timeline:alice" || feeds[1].FeedID() != "timeline:carol" {
+			t.Error(name, "unexpected followers:", feeds[0].FeedID(), feeds[1].FeedID())
+		}
+	}
+}
+
+func TestFeedCoreFollowingAcrossFeedTypes(t *testing.T) {
+	body := `{"duration": "1ms", "results": [
+		{"feed_id": "flat:bob", "target_id": "user:alice"}
+	]}`
+	client, last, done := newFeedCoreTestClient(t, body)
+	defer done()
+
+	flat, _ := client.FlatFeed("flat", "bob")
+	aggregated, _ := client.AggregatedFeed("flat", "bob")
+	notification, _ := client.NotificationFeed("flat", "bob")
+
+	for name, following := range map[string]func(int, int) ([]*getstream.GeneralFeed, error){
+		"flat":         flat.FollowingWithLimitAndSkip,
+		"aggregated":   aggregated.FollowingWithLimitAndSkip,
+		"notification": notification.FollowingWithLimitAndSkip,
+	} {
+		feeds, err := following(5, 0)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		if last.URL.Path != "/api/v1.0/feed/flat/bob/following/" {
+			t.Error(name, "unexpected path:", last.URL.Path)
+		}
+		if len(feeds) != 1 || feeds[0].FeedID() != "user:alice" {
+			t.Error(name, "expected the target feed, got:", feeds)
+		}
+	}
+}
+
+func TestFeedCoreActivitiesParams(t *testing.T) {
+	client, last, done := newFeedCoreTestClient(t, `{"duration": "1ms", "results": []}`)
+	defer done()
+
+	aggregated, _ := client.AggregatedFeed("aggregated", "bob")
+	_, err := aggregated.Activities(&getstream.GetAggregatedFeedInput{Limit: 3, IDLT: "abc", Ranking: "popular"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := last.URL.Query()
+	if query.Get("limit") != "3" || query.Get("id_lt") != "abc" || query.Get("ranking") != "popular" {
+		t.Error("Expected the aggregated input as query params, got:", last.URL.RawQuery)
+	}
+
+	notification, _ := client.NotificationFeed("notification", "bob")
+	_, err = notification.Activities(&getstream.GetNotificationFeedInput{Offset: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.URL.Query().Get("offset") != "7" {
+		t.Error("Expected the notification input as query params, got:", last.URL.RawQuery)
+	}
+
+	flat, _ := client.FlatFeed("flat", "bob")
+	_, err = flat.Activities(nil)
+	if err != nil {
+		t.Fatal("Expected a nil input to be accepted, got:", err)
+	}
+}
+
+func TestFeedCoreUnfollowKeepingHistory(t *testing.T) {
+	client, last, done := newFeedCoreTestClient(t, `{"duration": "1ms"}`)
+	defer done()
+
+	flat, _ := client.FlatFeed("flat", "bob")
+	target, _ := client.FlatFeed("user", "alice")
+
+	err := flat.UnfollowKeepingHistory(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Method != "DELETE" || last.URL.Path != "/api/v1.0/feed/flat/bob/following/user:alice/" {
+		t.Error("Unexpected request:", last.Method, last.URL.Path)
+	}
+	if last.URL.Query().Get("keep_history") != "1" {
+		t.Error("Expected keep_history as a query param, got:", last.URL.RawQuery)
+	}
+
+	err = flat.Unfollow(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.URL.Query().Get("keep_history") != "" {
+		t.Error("Expected no keep_history param, got:", last.URL.RawQuery)
+	}
+}
+
+func TestFeedCoreFollowStats(t *testing.T) {
+	body := `{"duration": "1ms", "results": {
+		"followers": {"feed": "user:bob", "count": 12},
+		"following": {"feed": "user:bob", "count": 3}
+	}}`
+	client, last, done := newFeedCoreTestClient(t, body)
+	defer done()
+
+	aggregated, _ := client.AggregatedFeed("user", "bob")
+
+	stats, err := aggregated.FollowStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Followers != 12 || stats.Following != 3 {
+		t.Error("Unexpected stats:", stats)
+	}
+
+	if last.URL.Path != "/api/v1.0/stats/follow/" {
+		t.Error("Unexpected path:", last.URL.Path)
+	}
+	if last.URL.Query().Get("followers") != "user:bob" || last.URL.Query().Get("following") != "user:bob" {
+		t.Error("Expected the feed id as followers and following params, got:", last.URL.RawQuery)
+	}
+	if last.Header.Get("stream-auth-type") != "jwt" || last.Header.Get("Authorization") == "" {
+		t.Error("Expected a jwt to be sent, got:", last.Header)
+	}
+}
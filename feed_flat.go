@@ -1,18 +1,12 @@
 package getstream
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"regexp"
 	"strconv"
-	"strings"
 )
 
-type postFlatFeedOutputActivities struct {
-	Activities []*Activity `json:"activities"`
-}
-
 // GetFlatFeedInput is used to Get a list of Activities from a FlatFeed
 type GetFlatFeedInput struct {
 	Limit  int
@@ -26,28 +20,12 @@ type GetFlatFeedInput struct {
 	Ranking string
 }
 
+// Params returns the query params for the input
 func (i *GetFlatFeedInput) Params() (params map[string]string) {
-	params = make(map[string]string)
-
-	if i.Limit != 0 {
-		params["limit"] = fmt.Sprintf("%d", i.Limit)
-	}
-	if i.Offset != 0 {
-		params["offset"] = fmt.Sprintf("%d", i.Offset)
-	}
-	if i.IDGTE != "" {
-		params["id_gte"] = i.IDGTE
-	}
-	if i.IDGT != "" {
-		params["id_gt"] = i.IDGT
-	}
-	if i.IDLTE != "" {
-		params["id_lte"] = i.IDLTE
-	}
-	if i.IDLT != "" {
-		params["id_lt"] = i.IDLT
+	if i == nil {
+		return map[string]string{}
 	}
-	return params
+	return readParams(i.Limit, i.Offset, i.IDGTE, i.IDGT, i.IDLTE, i.IDLT, i.Ranking)
 }
 
 // GetFlatFeedOutput is the response from a FlatFeed Activities Get Request
@@ -57,28 +35,6 @@ type GetFlatFeedOutput struct {
 	Activities []*Activity `json:"results"`
 }
 
-type getFlatFeedFollowersInput struct {
-	Limit int `json:"limit"`
-	Skip  int `json:"offset"`
-}
-
-type getFlatFeedFollowersOutput struct {
-	Duration string                              `json:"duration"`
-	Results  []*getFlatFeedFollowersOutputResult `json:"results"`
-}
-
-type getFlatFeedFollowersOutputResult struct {
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	FeedID    string `json:"feed_id"`
-	TargetID  string `json:"target_id"`
-}
-
-type postFeedFollowingInput struct {
-	Target            string `json:"target"`
-	ActivityCopyLimit int    `json:"activity_copy_limit"`
-}
-
 // FlatFeed is a getstream FlatFeed
 // Use it to for CRUD on FlatFeed Groups
 type FlatFeed struct {
@@ -88,28 +44,34 @@ type FlatFeed struct {
 	token    string
 }
 
+// core returns the shared feed implementation bound to this FlatFeed
+func (f *FlatFeed) core() *feedCore {
+	return &feedCore{
+		Client:   f.Client,
+		FeedSlug: f.FeedSlug,
+		UserID:   f.UserID,
+		token:    &f.token,
+	}
+}
+
 // Signature is used to sign Requests : "FeedSlugUserID Token"
 func (f *FlatFeed) Signature() string {
-	if f.Token() == "" {
-		return f.FeedIDWithoutColon()
-	}
-	return f.FeedIDWithoutColon() + " " + f.Token()
+	return f.core().Signature()
 }
 
 // FeedID is the combo if the FeedSlug and UserID : "FeedSlug:UserID"
 func (f *FlatFeed) FeedID() FeedID {
-	return FeedID(f.FeedSlug + ":" + f.UserID)
+	return f.core().FeedID()
 }
 
+// FeedIDWithoutColon is the combo if the FeedSlug and UserID : "FeedSlugUserID"
 func (f *FlatFeed) FeedIDWithoutColon() string {
-	return f.FeedSlug + f.UserID
+	return f.core().FeedIDWithoutColon()
 }
 
 // SignFeed sets the token on a Feed
 func (f *FlatFeed) SignFeed(signer *Signer) {
-	if f.Client.Signer != nil {
-		f.token = signer.GenerateToken(f.FeedIDWithoutColon())
-	}
+	f.core().SignFeed(signer)
 }
 
 // Token returns the token of a Feed
@@ -119,75 +81,37 @@ func (f *FlatFeed) Token() string {
 
 // GenerateToken returns a new Token for a Feed without setting it to the Feed
 func (f *FlatFeed) GenerateToken(signer *Signer) string {
-	if f.Client.Signer != nil {
-		return signer.GenerateToken(f.FeedSlug + f.UserID)
-	}
-	return ""
+	return f.core().GenerateToken(signer)
 }
 
 // AddActivity is used to add an Activity to a FlatFeed
 func (f *FlatFeed) AddActivity(activity *Activity) (*Activity, error) {
+	return f.AddActivityContext(context.Background(), activity)
+}
 
-	activity.ID = ""
-
-	payload, err := json.Marshal(activity)
-	if err != nil {
-		return nil, err
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	resultBytes, err := f.Client.post(f, endpoint, payload, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	output := &Activity{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	return output, err
+// AddActivityContext is the context.Context aware version of AddActivity
+func (f *FlatFeed) AddActivityContext(ctx context.Context, activity *Activity) (*Activity, error) {
+	return f.core().addActivity(ctx, activity)
 }
 
 // AddActivities is used to add multiple Activities to a FlatFeed
 func (f *FlatFeed) AddActivities(activities []*Activity) ([]*Activity, error) {
-	for _, activity := range activities {
-		activity.ID = ""
-	}
-
-	payload, err := json.Marshal(map[string][]*Activity{
-		"activities": activities,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	resultBytes, err := f.Client.post(f, endpoint, payload, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	output := &postFlatFeedOutputActivities{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
+	return f.AddActivitiesContext(context.Background(), activities)
+}
 
-	return output.Activities, err
+// AddActivitiesContext is the context.Context aware version of AddActivities
+func (f *FlatFeed) AddActivitiesContext(ctx context.Context, activities []*Activity) ([]*Activity, error) {
+	return f.core().addActivities(ctx, activities)
 }
 
 // Activities returns a list of Activities for a FlatFeedGroup
 func (f *FlatFeed) Activities(input *GetFlatFeedInput) (*GetFlatFeedOutput, error) {
-	var err error
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	result, err := f.Client.get(f, endpoint, nil, input.Params())
+	return f.ActivitiesContext(context.Background(), input)
+}
 
+// ActivitiesContext is the context.Context aware version of Activities
+func (f *FlatFeed) ActivitiesContext(ctx context.Context, input *GetFlatFeedInput) (*GetFlatFeedOutput, error) {
+	result, err := f.core().activities(ctx, input.Params())
 	if err != nil {
 		return nil, err
 	}
@@ -203,160 +127,75 @@ func (f *FlatFeed) Activities(input *GetFlatFeedInput) (*GetFlatFeedOutput, erro
 
 // RemoveActivity removes an Activity from a FlatFeedGroup
 func (f *FlatFeed) RemoveActivity(input *Activity) error {
+	return f.RemoveActivityContext(context.Background(), input)
+}
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + input.ID + "/"
-
-	return f.Client.del(f, endpoint, nil, nil)
+// RemoveActivityContext is the context.Context aware version of RemoveActivity
+func (f *FlatFeed) RemoveActivityContext(ctx context.Context, input *Activity) error {
+	return f.core().removeActivity(ctx, input)
 }
 
 // RemoveActivityByForeignID removes an Activity from a FlatFeedGroup by ForeignID
 func (f *FlatFeed) RemoveActivityByForeignID(input *Activity) error {
+	return f.RemoveActivityByForeignIDContext(context.Background(), input)
+}
 
-	if input.ForeignID == "" {
-		return errors.New("no ForeignID")
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + input.ForeignID + "/"
-
-	return f.Client.del(f, endpoint, nil, map[string]string{
-		"foreign_id": "1",
-	})
+// RemoveActivityByForeignIDContext is the context.Context aware version of RemoveActivityByForeignID
+func (f *FlatFeed) RemoveActivityByForeignIDContext(ctx context.Context, input *Activity) error {
+	return f.core().removeActivityByForeignID(ctx, input)
 }
 
 // FollowFeedWithCopyLimit sets a Feed to follow another target Feed
 // CopyLimit is the maximum number of Activities to Copy from History
 func (f *FlatFeed) FollowFeedWithCopyLimit(target *FlatFeed, copyLimit int) error {
+	return f.FollowFeedWithCopyLimitContext(context.Background(), target, copyLimit)
+}
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/"
-
-	input := postFeedFollowingInput{
-		Target:            target.FeedID().Value(),
-		ActivityCopyLimit: copyLimit,
-	}
-
-	payload, err := json.Marshal(input)
-	if err != nil {
-		return err
-	}
-
-	_, err = f.Client.post(f, endpoint, payload, nil)
-	return err
+// FollowFeedWithCopyLimitContext is the context.Context aware version of FollowFeedWithCopyLimit
+func (f *FlatFeed) FollowFeedWithCopyLimitContext(ctx context.Context, target *FlatFeed, copyLimit int) error {
+	return f.core().follow(ctx, target, copyLimit)
 }
 
 // Unfollow is used to Unfollow a target Feed
 func (f *FlatFeed) Unfollow(target *FlatFeed) error {
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/" + target.FeedID().Value() + "/"
+	return f.UnfollowContext(context.Background(), target)
+}
 
-	return f.Client.del(f, endpoint, nil, nil)
+// UnfollowContext is the context.Context aware version of Unfollow
+func (f *FlatFeed) UnfollowContext(ctx context.Context, target *FlatFeed) error {
+	return f.core().unfollow(ctx, target, false)
 }
 
 // UnfollowKeepingHistory is used to Unfollow a target Feed while keeping the History
 // this means that Activities already visibile will remain
 func (f *FlatFeed) UnfollowKeepingHistory(target *FlatFeed) error {
+	return f.UnfollowKeepingHistoryContext(context.Background(), target)
+}
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/" + target.FeedID().Value() + "/"
-
-	payload, err := json.Marshal(map[string]string{
-		"keep_history": "1",
-	})
-	if err != nil {
-		return err
-	}
-
-	return f.Client.del(f, endpoint, payload, nil)
+// UnfollowKeepingHistoryContext is the context.Context aware version of UnfollowKeepingHistory
+func (f *FlatFeed) UnfollowKeepingHistoryContext(ctx context.Context, target *FlatFeed) error {
+	return f.core().unfollow(ctx, target, true)
 }
 
 // FollowersWithLimitAndSkip returns a list of GeneralFeed following the current FlatFeed
 func (f *FlatFeed) FollowersWithLimitAndSkip(limit int, skip int) ([]*GeneralFeed, error) {
-	var err error
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "followers" + "/"
-
-	payload, err := json.Marshal(&getFlatFeedFollowersInput{
-		Limit: limit,
-		Skip:  skip,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	resultBytes, err := f.Client.get(f, endpoint, payload, nil)
-
-	output := &getFlatFeedFollowersOutput{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	var outputFeeds []*GeneralFeed
-	for _, result := range output.Results {
-
-		feed := GeneralFeed{}
-
-		var match bool
-		match, err = regexp.MatchString(`^.*?:.*?$`, result.FeedID)
-		if err != nil {
-			continue
-		}
-
-		if match {
-			firstSplit := strings.Split(result.FeedID, ":")
-
-			feed.FeedSlug = firstSplit[0]
-			feed.UserID = firstSplit[1]
-		}
-
-		outputFeeds = append(outputFeeds, &feed)
-	}
+	return f.FollowersWithLimitAndSkipContext(context.Background(), limit, skip)
+}
 
-	return outputFeeds, err
+// FollowersWithLimitAndSkipContext is the context.Context aware version of FollowersWithLimitAndSkip
+func (f *FlatFeed) FollowersWithLimitAndSkipContext(ctx context.Context, limit int, skip int) ([]*GeneralFeed, error) {
+	return f.core().followers(ctx, limit, skip)
 }
 
 // FollowingWithLimitAndSkip returns a list of GeneralFeed followed by the current FlatFeed
 // TODO: need to support filters
 func (f *FlatFeed) FollowingWithLimitAndSkip(limit int, skip int) ([]*GeneralFeed, error) {
-	var err error
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/"
-
-	payload, err := json.Marshal(&getFlatFeedFollowersInput{
-		Limit: limit,
-		Skip:  skip,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	resultBytes, err := f.Client.get(f, endpoint, payload, nil)
-
-	output := &getFlatFeedFollowersOutput{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	var outputFeeds []*GeneralFeed
-	for _, result := range output.Results {
-
-		feed := GeneralFeed{}
-
-		var match bool
-		match, err = regexp.MatchString(`^.*?:.*?$`, result.FeedID)
-		if err != nil {
-			continue
-		}
-
-		if match {
-			firstSplit := strings.Split(result.TargetID, ":")
-
-			feed.FeedSlug = firstSplit[0]
-			feed.UserID = firstSplit[1]
-		}
-
-		outputFeeds = append(outputFeeds, &feed)
-	}
+	return f.FollowingWithLimitAndSkipContext(context.Background(), limit, skip)
+}
 
-	return outputFeeds, err
+// FollowingWithLimitAndSkipContext is the context.Context aware version of FollowingWithLimitAndSkip
+func (f *FlatFeed) FollowingWithLimitAndSkipContext(ctx context.Context, limit int, skip int) ([]*GeneralFeed, error) {
+	return f.core().following(ctx, limit, skip)
 }
 
 /** FollowFeedsWithCopyLimit sets a Feed to follow one or more other target Feeds
@@ -370,6 +209,11 @@ func (f *FlatFeed) FollowingWithLimitAndSkip(limit int, skip int) ([]*GeneralFee
  	error, if any
 */
 func (f *FlatFeed) FollowManyFeeds(sourceFeeds []PostFlatFeedFollowingManyInput, copyLimit int) error {
+	return f.FollowManyFeedsContext(context.Background(), sourceFeeds, copyLimit)
+}
+
+// FollowManyFeedsContext is the context.Context aware version of FollowManyFeeds
+func (f *FlatFeed) FollowManyFeedsContext(ctx context.Context, sourceFeeds []PostFlatFeedFollowingManyInput, copyLimit int) error {
 
 	final_payload, err := json.Marshal(sourceFeeds)
 	if err != nil {
@@ -392,7 +236,7 @@ func (f *FlatFeed) FollowManyFeeds(sourceFeeds []PostFlatFeedFollowingManyInput,
 	//save_token = f.token
 	//f.token = ""
 	//}
-	_, err = f.Client.post(f, endpoint, final_payload, params)
+	_, err = f.Client.post(ctx, f, endpoint, final_payload, params)
 	//if save_token != "" {
 	//fmt.Println("restoring token")
 	//f.token = save_token
@@ -405,6 +249,11 @@ type postMultipleActivities struct {
 }
 
 func (f *FlatFeed) UpdateActivities(activities []*Activity) error {
+	return f.UpdateActivitiesContext(context.Background(), activities)
+}
+
+// UpdateActivitiesContext is the context.Context aware version of UpdateActivities
+func (f *FlatFeed) UpdateActivitiesContext(ctx context.Context, activities []*Activity) error {
 	if len(activities) == 0 {
 		return errors.New("No activities to update")
 	}
@@ -435,7 +284,10 @@ func (f *FlatFeed) UpdateActivities(activities []*Activity) error {
 	endpoint := "activities/"
 	params := map[string]string{}
 
-	_, err = f.Client.post(f, endpoint, final_payload, params)
+	// updates are keyed by ForeignID and are safe to retry
+	ctx = withIdempotentWrite(ctx)
+
+	_, err = f.Client.post(ctx, f, endpoint, final_payload, params)
 	if err != nil {
 		return err
 	}
@@ -444,5 +296,42 @@ func (f *FlatFeed) UpdateActivities(activities []*Activity) error {
 }
 
 func (f *FlatFeed) UpdateActivity(activity *Activity) error {
-	return f.UpdateActivities([]*Activity{activity})
+	return f.UpdateActivityContext(context.Background(), activity)
+}
+
+// UpdateActivityContext is the context.Context aware version of UpdateActivity
+func (f *FlatFeed) UpdateActivityContext(ctx context.Context, activity *Activity) error {
+	return f.UpdateActivitiesContext(ctx, []*Activity{activity})
+}
+
+// IterateFollowers returns an iterator over the feeds following the current FlatFeed
+// pageSize is the number of feeds read per request, 0 uses the API default
+func (f *FlatFeed) IterateFollowers(pageSize int) *FollowIterator {
+	return f.IterateFollowersContext(context.Background(), pageSize)
+}
+
+// IterateFollowersContext is the context.Context aware version of IterateFollowers
+func (f *FlatFeed) IterateFollowersContext(ctx context.Context, pageSize int) *FollowIterator {
+	return f.core().iterateFollows(ctx, "followers", pageSize)
+}
+
+// IterateFollowing returns an iterator over the feeds followed by the current FlatFeed
+// pageSize is the number of feeds read per request, 0 uses the API default
+func (f *FlatFeed) IterateFollowing(pageSize int) *FollowIterator {
+	return f.IterateFollowingContext(context.Background(), pageSize)
+}
+
+// IterateFollowingContext is the context.Context aware version of IterateFollowing
+func (f *FlatFeed) IterateFollowingContext(ctx context.Context, pageSize int) *FollowIterator {
+	return f.core().iterateFollows(ctx, "following", pageSize)
+}
+
+// FollowStats returns the number of feeds following the current FlatFeed and the number of feeds it follows
+func (f *FlatFeed) FollowStats() (*FollowStats, error) {
+	return f.FollowStatsContext(context.Background())
+}
+
+// FollowStatsContext is the context.Context aware version of FollowStats
+func (f *FlatFeed) FollowStatsContext(ctx context.Context) (*FollowStats, error) {
+	return f.core().followStats(ctx)
 }
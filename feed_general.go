@@ -1,5 +1,7 @@
 package getstream
 
+import "context"
+
 // GeneralFeed is a container for Feeds returned from request
 // The specific Type will be unknown so no Actions are associated with a GeneralFeed
 type GeneralFeed struct {
@@ -9,28 +11,34 @@ type GeneralFeed struct {
 	token    string
 }
 
+// core returns the shared feed implementation bound to this GeneralFeed
+func (f *GeneralFeed) core() *feedCore {
+	return &feedCore{
+		Client:   f.Client,
+		FeedSlug: f.FeedSlug,
+		UserID:   f.UserID,
+		token:    &f.token,
+	}
+}
+
 // Signature is used to sign Requests : "FeedSlugUserID Token"
 func (f *GeneralFeed) Signature() string {
-	if f.Token() == "" {
-		return f.FeedIDWithoutColon()
-	}
-	return f.FeedIDWithoutColon() + " " + f.Token()
+	return f.core().Signature()
 }
 
 // FeedID is the combo if the FeedSlug and UserID : "FeedSlug:UserID"
 func (f *GeneralFeed) FeedID() FeedID {
-	return FeedID(f.FeedSlug + ":" + f.UserID)
+	return f.core().FeedID()
 }
 
+// FeedIDWithoutColon is the combo if the FeedSlug and UserID : "FeedSlugUserID"
 func (f *GeneralFeed) FeedIDWithoutColon() string {
-	return f.FeedSlug + f.UserID
+	return f.core().FeedIDWithoutColon()
 }
 
 // SignFeed sets the token on a Feed
 func (f *GeneralFeed) SignFeed(signer *Signer) {
-	if f.Client.Signer != nil {
-		f.token = signer.GenerateToken(f.FeedIDWithoutColon())
-	}
+	f.core().SignFeed(signer)
 }
 
 // Token returns the token of a Feed
@@ -40,38 +48,43 @@ func (f *GeneralFeed) Token() string {
 
 // GenerateToken returns a new Token for a Feed without setting it to the Feed
 func (f *GeneralFeed) GenerateToken(signer *Signer) string {
-	if f.Client.Signer != nil {
-		return signer.GenerateToken(f.FeedSlug + f.UserID)
-	}
-	return ""
+	return f.core().GenerateToken(signer)
 }
 
 // Unfollow is used to Unfollow a target Feed
 func (f *GeneralFeed) Unfollow(client *Client, target *FlatFeed) error {
-	f.Client = client
-	f.SignFeed(f.Client.Signer)
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/" + target.FeedID().Value() + "/"
+	return f.UnfollowContext(context.Background(), client, target)
+}
 
-	return f.Client.del(f, endpoint, nil, nil)
+// UnfollowContext is the context.Context aware version of Unfollow
+func (f *GeneralFeed) UnfollowContext(ctx context.Context, client *Client, target *FlatFeed) error {
+	return f.unfollow(ctx, client, target)
 }
 
 // UnfollowAggregated is used to Unfollow a target Aggregated Feed
 func (f *GeneralFeed) UnfollowAggregated(client *Client, target *AggregatedFeed) error {
-	f.Client = client
-	f.SignFeed(f.Client.Signer)
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/" + target.FeedID().Value() + "/"
+	return f.UnfollowAggregatedContext(context.Background(), client, target)
+}
 
-	return f.Client.del(f, endpoint, nil, nil)
+// UnfollowAggregatedContext is the context.Context aware version of UnfollowAggregated
+func (f *GeneralFeed) UnfollowAggregatedContext(ctx context.Context, client *Client, target *AggregatedFeed) error {
+	return f.unfollow(ctx, client, target)
 }
 
 // UnfollowNotification is used to Unfollow a target Notification Feed
 func (f *GeneralFeed) UnfollowNotification(client *Client, target *NotificationFeed) error {
+	return f.UnfollowNotificationContext(context.Background(), client, target)
+}
+
+// UnfollowNotificationContext is the context.Context aware version of UnfollowNotification
+func (f *GeneralFeed) UnfollowNotificationContext(ctx context.Context, client *Client, target *NotificationFeed) error {
+	return f.unfollow(ctx, client, target)
+}
+
+// unfollow binds the GeneralFeed to a client before unfollowing the target
+func (f *GeneralFeed) unfollow(ctx context.Context, client *Client, target Feed) error {
 	f.Client = client
 	f.SignFeed(f.Client.Signer)
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/" + target.FeedID().Value() + "/"
-
-	return f.Client.del(f, endpoint, nil, nil)
+	return f.core().unfollow(ctx, target, false)
 }
@@ -1,17 +1,12 @@
 package getstream
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"regexp"
 	"strconv"
 	"strings"
 )
 
-type postNotificationFeedOutputActivities struct {
-	Activities []*Activity `json:"activities"`
-}
-
 // GetNotificationFeedInput is used to Get a list of Activities from a NotificationFeed
 type GetNotificationFeedInput struct {
 	Limit  int `json:"limit,omitempty"`
@@ -25,24 +20,36 @@ type GetNotificationFeedInput struct {
 	Ranking string `json:"ranking,omitempty"`
 }
 
+// Params returns the query params for the input
+func (i *GetNotificationFeedInput) Params() (params map[string]string) {
+	if i == nil {
+		return map[string]string{}
+	}
+	return readParams(i.Limit, i.Offset, i.IDGTE, i.IDGT, i.IDLTE, i.IDLT, i.Ranking)
+}
+
+// NotificationFeedGroup is a group of Activities returned by a NotificationFeed
+// It is an alias so the Results of GetNotificationFeedOutput keep their original type
+type NotificationFeedGroup = struct {
+	Activities    []*Activity
+	ActivityCount int
+	ActorCount    int
+	CreatedAt     string
+	Group         string
+	ID            string
+	IsRead        bool
+	IsSeen        bool
+	UpdatedAt     string
+	Verb          string
+}
+
 // GetNotificationFeedOutput is the response from a NotificationFeed Activities Get Request
 type GetNotificationFeedOutput struct {
 	Duration string
 	Next     string
-	Results  []*struct {
-		Activities    []*Activity
-		ActivityCount int
-		ActorCount    int
-		CreatedAt     string
-		Group         string
-		ID            string
-		IsRead        bool
-		IsSeen        bool
-		UpdatedAt     string
-		Verb          string
-	}
-	Unread int
-	Unseen int
+	Results  []*NotificationFeedGroup
+	Unread   int
+	Unseen   int
 }
 
 type getNotificationFeedOutput struct {
@@ -62,33 +69,11 @@ func (a getNotificationFeedOutput) output() *GetNotificationFeedOutput {
 		Unseen:   a.Unseen,
 	}
 
-	var results []*struct {
-		Activities    []*Activity
-		ActivityCount int
-		ActorCount    int
-		CreatedAt     string
-		Group         string
-		ID            string
-		IsRead        bool
-		IsSeen        bool
-		UpdatedAt     string
-		Verb          string
-	}
+	var results []*NotificationFeedGroup
 
 	for _, result := range a.Results {
 
-		outputResult := struct {
-			Activities    []*Activity
-			ActivityCount int
-			ActorCount    int
-			CreatedAt     string
-			Group         string
-			ID            string
-			IsRead        bool
-			IsSeen        bool
-			UpdatedAt     string
-			Verb          string
-		}{
+		outputResult := NotificationFeedGroup{
 			ActivityCount: result.ActivityCount,
 			ActorCount:    result.ActorCount,
 			CreatedAt:     result.CreatedAt,
@@ -125,28 +110,6 @@ type getNotificationFeedOutputResult struct {
 	Verb          string      `json:"verb"`
 }
 
-type getNotificationFeedFollowersInput struct {
-	Limit int `json:"limit"`
-	Skip  int `json:"offset"`
-}
-
-type getNotificationFeedFollowersOutput struct {
-	Duration string                                      `json:"duration"`
-	Results  []*getNotificationFeedFollowersOutputResult `json:"results"`
-}
-
-type getNotificationFeedFollowersOutputResult struct {
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	FeedID    string `json:"feed_id"`
-	TargetID  string `json:"target_id"`
-}
-
-type postNotificationFeedFollowingInput struct {
-	Target            string `json:"target"`
-	ActivityCopyLimit int    `json:"activity_copy_limit"`
-}
-
 // NotificationFeed is a getstream NotificationFeed
 // Use it to for CRUD on NotificationFeed Groups
 type NotificationFeed struct {
@@ -156,28 +119,34 @@ type NotificationFeed struct {
 	token    string
 }
 
+// core returns the shared feed implementation bound to this NotificationFeed
+func (f *NotificationFeed) core() *feedCore {
+	return &feedCore{
+		Client:   f.Client,
+		FeedSlug: f.FeedSlug,
+		UserID:   f.UserID,
+		token:    &f.token,
+	}
+}
+
 // Signature is used to sign Requests : "FeedSlugUserID Token"
 func (f *NotificationFeed) Signature() string {
-	if f.Token() == "" {
-		return f.FeedIDWithoutColon()
-	}
-	return f.FeedIDWithoutColon() + " " + f.Token()
+	return f.core().Signature()
 }
 
 // FeedID is the combo if the FeedSlug and UserID : "FeedSlug:UserID"
 func (f *NotificationFeed) FeedID() FeedID {
-	return FeedID(f.FeedSlug + ":" + f.UserID)
+	return f.core().FeedID()
 }
 
+// FeedIDWithoutColon is the combo if the FeedSlug and UserID : "FeedSlugUserID"
 func (f *NotificationFeed) FeedIDWithoutColon() string {
-	return f.FeedSlug + f.UserID
+	return f.core().FeedIDWithoutColon()
 }
 
 // SignFeed sets the token on a Feed
 func (f *NotificationFeed) SignFeed(signer *Signer) {
-	if f.Client.Signer != nil {
-		f.token = signer.GenerateToken(f.FeedIDWithoutColon())
-	}
+	f.core().SignFeed(signer)
 }
 
 // Token returns the token of a Feed
@@ -187,64 +156,36 @@ func (f *NotificationFeed) Token() string {
 
 // GenerateToken returns a new Token for a Feed without setting it to the Feed
 func (f *NotificationFeed) GenerateToken(signer *Signer) string {
-	if f.Client.Signer != nil {
-		return signer.GenerateToken(f.FeedSlug + f.UserID)
-	}
-	return ""
+	return f.core().GenerateToken(signer)
 }
 
 // AddActivity is used to add an Activity to a NotificationFeed
 func (f *NotificationFeed) AddActivity(activity *Activity) (*Activity, error) {
+	return f.AddActivityContext(context.Background(), activity)
+}
 
-	payload, err := json.Marshal(activity)
-	if err != nil {
-		return nil, err
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	resultBytes, err := f.Client.post(f, endpoint, payload, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	output := &Activity{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	return output, err
+// AddActivityContext is the context.Context aware version of AddActivity
+func (f *NotificationFeed) AddActivityContext(ctx context.Context, activity *Activity) (*Activity, error) {
+	return f.core().addActivity(ctx, activity)
 }
 
 // AddActivities is used to add multiple Activities to a NotificationFeed
 func (f *NotificationFeed) AddActivities(activities []*Activity) ([]*Activity, error) {
+	return f.AddActivitiesContext(context.Background(), activities)
+}
 
-	payload, err := json.Marshal(map[string][]*Activity{
-		"activities": activities,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	resultBytes, err := f.Client.post(f, endpoint, payload, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	output := &postNotificationFeedOutputActivities{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	return output.Activities, err
+// AddActivitiesContext is the context.Context aware version of AddActivities
+func (f *NotificationFeed) AddActivitiesContext(ctx context.Context, activities []*Activity) ([]*Activity, error) {
+	return f.core().addActivities(ctx, activities)
 }
 
 // MarkActivitiesAsRead marks activities as read for this feed
 func (f *NotificationFeed) MarkActivitiesAsRead(activities []*Activity) error {
+	return f.MarkActivitiesAsReadContext(context.Background(), activities)
+}
+
+// MarkActivitiesAsReadContext is the context.Context aware version of MarkActivitiesAsRead
+func (f *NotificationFeed) MarkActivitiesAsReadContext(ctx context.Context, activities []*Activity) error {
 
 	var ids []string
 	for _, activity := range activities {
@@ -253,9 +194,7 @@ func (f *NotificationFeed) MarkActivitiesAsRead(activities []*Activity) error {
 
 	idStr := strings.Join(ids, ",")
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	_, err := f.Client.get(f, endpoint, nil, map[string]string{
+	_, err := f.core().activities(ctx, map[string]string{
 		"mark_read": idStr,
 	})
 
@@ -264,10 +203,13 @@ func (f *NotificationFeed) MarkActivitiesAsRead(activities []*Activity) error {
 
 // MarkActivitiesAsSeenWithLimit marks activities as seen for this feed
 func (f *NotificationFeed) MarkActivitiesAsSeenWithLimit(limit int) error {
+	return f.MarkActivitiesAsSeenWithLimitContext(context.Background(), limit)
+}
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
+// MarkActivitiesAsSeenWithLimitContext is the context.Context aware version of MarkActivitiesAsSeenWithLimit
+func (f *NotificationFeed) MarkActivitiesAsSeenWithLimitContext(ctx context.Context, limit int) error {
 
-	_, err := f.Client.get(f, endpoint, nil, map[string]string{
+	_, err := f.core().activities(ctx, map[string]string{
 		"mark_seen": "true",
 		"limit":     strconv.Itoa(limit),
 	})
@@ -277,20 +219,12 @@ func (f *NotificationFeed) MarkActivitiesAsSeenWithLimit(limit int) error {
 
 // Activities returns a list of Activities for a NotificationFeedGroup
 func (f *NotificationFeed) Activities(input *GetNotificationFeedInput) (*GetNotificationFeedOutput, error) {
+	return f.ActivitiesContext(context.Background(), input)
+}
 
-	var payload []byte
-	var err error
-
-	if input != nil {
-		payload, err = json.Marshal(input)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/"
-
-	result, err := f.Client.get(f, endpoint, payload, nil)
+// ActivitiesContext is the context.Context aware version of Activities
+func (f *NotificationFeed) ActivitiesContext(ctx context.Context, input *GetNotificationFeedInput) (*GetNotificationFeedOutput, error) {
+	result, err := f.core().activities(ctx, input.Params())
 	if err != nil {
 		return nil, err
 	}
@@ -306,162 +240,105 @@ func (f *NotificationFeed) Activities(input *GetNotificationFeedInput) (*GetNoti
 
 // RemoveActivity removes an Activity from a NotificationFeedGroup
 func (f *NotificationFeed) RemoveActivity(input *Activity) error {
+	return f.RemoveActivityContext(context.Background(), input)
+}
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + input.ID + "/"
-
-	return f.Client.del(f, endpoint, nil, nil)
+// RemoveActivityContext is the context.Context aware version of RemoveActivity
+func (f *NotificationFeed) RemoveActivityContext(ctx context.Context, input *Activity) error {
+	return f.core().removeActivity(ctx, input)
 }
 
 // RemoveActivityByForeignID removes an Activity from a NotificationFeedGroup by ForeignID
 func (f *NotificationFeed) RemoveActivityByForeignID(input *Activity) error {
+	return f.RemoveActivityByForeignIDContext(context.Background(), input)
+}
 
-	if input.ForeignID == "" {
-		return errors.New("no ForeignID")
-	}
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + input.ForeignID + "/"
-
-	return f.Client.del(f, endpoint, nil, map[string]string{
-		"foreign_id": "1",
-	})
+// RemoveActivityByForeignIDContext is the context.Context aware version of RemoveActivityByForeignID
+func (f *NotificationFeed) RemoveActivityByForeignIDContext(ctx context.Context, input *Activity) error {
+	return f.core().removeActivityByForeignID(ctx, input)
 }
 
 // FollowFeedWithCopyLimit sets a Feed to follow another target Feed
 // CopyLimit is the maximum number of Activities to Copy from History
 func (f *NotificationFeed) FollowFeedWithCopyLimit(target *FlatFeed, copyLimit int) error {
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/"
-
-	input := postNotificationFeedFollowingInput{
-		Target:            target.FeedID().Value(),
-		ActivityCopyLimit: copyLimit,
-	}
-
-	payload, err := json.Marshal(input)
-	if err != nil {
-		return err
-	}
-
-	_, err = f.Client.post(f, endpoint, payload, nil)
-	return err
+	return f.FollowFeedWithCopyLimitContext(context.Background(), target, copyLimit)
+}
 
+// FollowFeedWithCopyLimitContext is the context.Context aware version of FollowFeedWithCopyLimit
+func (f *NotificationFeed) FollowFeedWithCopyLimitContext(ctx context.Context, target *FlatFeed, copyLimit int) error {
+	return f.core().follow(ctx, target, copyLimit)
 }
 
 // Unfollow is used to Unfollow a target Feed
 func (f *NotificationFeed) Unfollow(target *FlatFeed) error {
+	return f.UnfollowContext(context.Background(), target)
+}
 
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/" + target.FeedID().Value() + "/"
-
-	return f.Client.del(f, endpoint, nil, nil)
-
+// UnfollowContext is the context.Context aware version of Unfollow
+func (f *NotificationFeed) UnfollowContext(ctx context.Context, target *FlatFeed) error {
+	return f.core().unfollow(ctx, target, false)
 }
 
 // UnfollowKeepingHistory is used to Unfollow a target Feed while keeping the History
 // this means that Activities already visibile will remain
 func (f *NotificationFeed) UnfollowKeepingHistory(target *FlatFeed) error {
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/" + target.FeedID().Value() + "/"
-
-	payload, err := json.Marshal(map[string]string{
-		"keep_history": "1",
-	})
-	if err != nil {
-		return err
-	}
-
-	return f.Client.del(f, endpoint, payload, nil)
-
+	return f.UnfollowKeepingHistoryContext(context.Background(), target)
 }
 
-// FollowingWithLimitAndSkip returns a list of GeneralFeed followed by the current FlatFeed
-func (f *NotificationFeed) FollowingWithLimitAndSkip(limit int, skip int) ([]*GeneralFeed, error) {
-
-	var err error
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "following" + "/"
-
-	payload, err := json.Marshal(&getNotificationFeedFollowersInput{
-		Limit: limit,
-		Skip:  skip,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	resultBytes, err := f.Client.get(f, endpoint, payload, nil)
-
-	output := &getNotificationFeedFollowersOutput{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
-
-	var outputFeeds []*GeneralFeed
-	for _, result := range output.Results {
-
-		feed := GeneralFeed{}
-
-		var match bool
-		match, err = regexp.MatchString(`^.*?:.*?$`, result.FeedID)
-		if err != nil {
-			continue
-		}
-
-		if match {
-			firstSplit := strings.Split(result.TargetID, ":")
-
-			feed.FeedSlug = firstSplit[0]
-			feed.UserID = firstSplit[1]
-		}
-
-		outputFeeds = append(outputFeeds, &feed)
-	}
-
-	return outputFeeds, err
-
+// UnfollowKeepingHistoryContext is the context.Context aware version of UnfollowKeepingHistory
+func (f *NotificationFeed) UnfollowKeepingHistoryContext(ctx context.Context, target *FlatFeed) error {
+	return f.core().unfollow(ctx, target, true)
 }
 
-// FollowersWithLimitAndSkip returns a list of GeneralFeed following the current FlatFeed
+// FollowersWithLimitAndSkip returns a list of GeneralFeed following the current NotificationFeed
 func (f *NotificationFeed) FollowersWithLimitAndSkip(limit int, skip int) ([]*GeneralFeed, error) {
-	var err error
-
-	endpoint := "feed/" + f.FeedSlug + "/" + f.UserID + "/" + "followers" + "/"
-
-	payload, err := json.Marshal(&getFlatFeedFollowersInput{
-		Limit: limit,
-		Skip:  skip,
-	})
-	if err != nil {
-		return nil, err
-	}
+	return f.FollowersWithLimitAndSkipContext(context.Background(), limit, skip)
+}
 
-	resultBytes, err := f.Client.get(f, endpoint, payload, nil)
+// FollowersWithLimitAndSkipContext is the context.Context aware version of FollowersWithLimitAndSkip
+func (f *NotificationFeed) FollowersWithLimitAndSkipContext(ctx context.Context, limit int, skip int) ([]*GeneralFeed, error) {
+	return f.core().followers(ctx, limit, skip)
+}
 
-	output := &getFlatFeedFollowersOutput{}
-	err = json.Unmarshal(resultBytes, output)
-	if err != nil {
-		return nil, err
-	}
+// FollowingWithLimitAndSkip returns a list of GeneralFeed followed by the current NotificationFeed
+// TODO: need to support filters
+func (f *NotificationFeed) FollowingWithLimitAndSkip(limit int, skip int) ([]*GeneralFeed, error) {
+	return f.FollowingWithLimitAndSkipContext(context.Background(), limit, skip)
+}
 
-	var outputFeeds []*GeneralFeed
-	for _, result := range output.Results {
+// FollowingWithLimitAndSkipContext is the context.Context aware version of FollowingWithLimitAndSkip
+func (f *NotificationFeed) FollowingWithLimitAndSkipContext(ctx context.Context, limit int, skip int) ([]*GeneralFeed, error) {
+	return f.core().following(ctx, limit, skip)
+}
 
-		feed := GeneralFeed{}
+// IterateFollowers returns an iterator over the feeds following the current NotificationFeed
+// pageSize is the number of feeds read per request, 0 uses the API default
+func (f *NotificationFeed) IterateFollowers(pageSize int) *FollowIterator {
+	return f.IterateFollowersContext(context.Background(), pageSize)
+}
 
-		var match bool
-		match, err = regexp.MatchString(`^.*?:.*?$`, result.FeedID)
-		if err != nil {
-			continue
-		}
+// IterateFollowersContext is the context.Context aware version of IterateFollowers
+func (f *NotificationFeed) IterateFollowersContext(ctx context.Context, pageSize int) *FollowIterator {
+	return f.core().iterateFollows(ctx, "followers", pageSize)
+}
 
-		if match {
-			firstSplit := strings.Split(result.FeedID, ":")
+// IterateFollowing returns an iterator over the feeds followed by the current NotificationFeed
+// pageSize is the number of feeds read per request, 0 uses the API default
+func (f *NotificationFeed) IterateFollowing(pageSize int) *FollowIterator {
+	return f.IterateFollowingContext(context.Background(), pageSize)
+}
 
-			feed.FeedSlug = firstSplit[0]
-			feed.UserID = firstSplit[1]
-		}
+// IterateFollowingContext is the context.Context aware version of IterateFollowing
+func (f *NotificationFeed) IterateFollowingContext(ctx context.Context, pageSize int) *FollowIterator {
+	return f.core().iterateFollows(ctx, "following", pageSize)
+}
 
-		outputFeeds = append(outputFeeds, &feed)
-	}
+// FollowStats returns the number of feeds following the current NotificationFeed and the number of feeds it follows
+func (f *NotificationFeed) FollowStats() (*FollowStats, error) {
+	return f.FollowStatsContext(context.Background())
+}
 
-	return outputFeeds, err
+// FollowStatsContext is the context.Context aware version of FollowStats
+func (f *NotificationFeed) FollowStatsContext(ctx context.Context) (*FollowStats, error) {
+	return f.core().followStats(ctx)
 }
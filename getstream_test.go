@@ -1,9 +1,18 @@
 package getstream_test
 
 import (
+	"net/url"
 	"os"
+	"sync"
+	"testing"
 
 	getstream "github.com/GetStream/stream-go"
+	"github.com/GetStream/stream-go/getstreamtest"
+)
+
+var (
+	fakeServer     *getstreamtest.Server
+	fakeServerOnce sync.Once
 )
 
 func PreTestSetup() (*getstream.Client, error) {
@@ -27,7 +36,45 @@ func PreTestSetupWithToken() (*getstream.Client, error) {
 }
 
 func doTestSetup(cfg *getstream.Config) (*getstream.Client, error) {
-	return getstream.New(cfg)
+	if cfg.APIKey != "" {
+		return getstream.New(cfg)
+	}
+
+	// without STREAM_API_KEY the tests run against an in-memory fake of the API
+	fakeServerOnce.Do(func() {
+		fakeServer = getstreamtest.NewServer("fake_key", "fake_secret")
+	})
+
+	cfg.APIKey = fakeServer.APIKey
+	cfg.APISecret = fakeServer.APISecret
+	return fakeServer.NewClientWithConfig(cfg)
+}
+
+// newServerTestClient returns a client sending its requests to a test server at serverURL, opts adjust its config
+func newServerTestClient(t *testing.T, serverURL string, opts ...getstream.Option) *getstream.Client {
+	t.Helper()
+
+	baseURL, err := url.Parse(serverURL + "/api/v1.0/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &getstream.Config{
+		APIKey:    "my_key",
+		APISecret: "my_secret",
+		AppID:     "111111",
+		Location:  "us-east",
+		BaseURL:   baseURL,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	client, err := getstream.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
 }
 
 func PostTestCleanUp(
@@ -0,0 +1,123 @@
+package getstreamtest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+// authenticate checks the credentials of a request for a resource
+// feed is the feed the request is about, empty for endpoints which are not bound to a feed
+// Requests can carry a JWT, an http signature of the application, or the token of the feed
+func (s *Server) authenticate(r *request, feed feedID, resource getstream.ScopeContext) *apiError {
+	authorization := r.Header.Get("Authorization")
+
+	switch {
+	case strings.ToLower(r.Header.Get("stream-auth-type")) == "jwt":
+		return s.authenticateJWT(r, feed, resource)
+	case strings.HasPrefix(authorization, "Signature "):
+		return s.authenticateApp(r)
+	case feed == "":
+		return newAPIError(http.StatusUnauthorized, "AuthenticationFailed", "this endpoint requires a JWT or an http signature")
+	}
+
+	if authorization == "" {
+		return newAPIError(http.StatusUnauthorized, "AuthenticationFailed", "missing authorization header")
+	}
+	if authorization != feed.withoutColon()+" "+s.feedToken(feed) {
+		return newAPIError(http.StatusForbidden, "NotAllowedException", "the feed token is invalid for feed "+string(feed))
+	}
+	return nil
+}
+
+// feedToken is the token the API expects for a feed
+func (s *Server) feedToken(feed feedID) string {
+	return getstream.Signer{Secret: s.APISecret}.GenerateToken(feed.withoutColon())
+}
+
+// authenticateJWT checks the JWT of a request is signed with the secret and grants access to the resource
+func (s *Server) authenticateJWT(r *request, feed feedID, resource getstream.ScopeContext) *apiError {
+	tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	claims, err := getstream.Signer{Secret: s.APISecret}.VerifyToken(tokenString)
+	if err != nil {
+		return newAPIError(http.StatusForbidden, "NotAllowedException", "the JWT is invalid: "+err.Error())
+	}
+
+	action := methodAction(r.Method)
+	if !claims.Permits(resource, action, feed.withoutColon()) {
+		return newAPIError(http.StatusForbidden, "NotAllowedException",
+			"the JWT does not grant "+action.Value()+" access to "+resource.Value()+" of feed "+string(feed))
+	}
+	return nil
+}
+
+// methodAction is the JWT action needed for an http method
+func methodAction(method string) getstream.ScopeAction {
+	switch method {
+	case http.MethodGet, http.MethodOptions, http.MethodHead:
+		return getstream.ScopeActionRead
+	case http.MethodDelete:
+		return getstream.ScopeActionDelete
+	}
+	return getstream.ScopeActionWrite
+}
+
+// authenticateApp checks the http signature of a request, as sent for application level endpoints
+// https://tools.ietf.org/html/draft-cavage-http-signatures
+func (s *Server) authenticateApp(r *request) *apiError {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Signature ") {
+		return newAPIError(http.StatusUnauthorized, "AuthenticationFailed", "this endpoint requires an http signature")
+	}
+
+	params := parseSignatureParams(strings.TrimPrefix(authorization, "Signature "))
+	if params["keyId"] != s.APIKey {
+		return newAPIError(http.StatusForbidden, "NotAllowedException", "the http signature keyId is invalid")
+	}
+	if algorithm := params["algorithm"]; algorithm != "" && algorithm != "hmac-sha256" {
+		return newAPIError(http.StatusForbidden, "NotAllowedException", "unsupported http signature algorithm "+algorithm)
+	}
+
+	headers := strings.Fields(params["headers"])
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+
+	var lines []string
+	for _, header := range headers {
+		header = strings.ToLower(header)
+		if header == "(request-target)" {
+			lines = append(lines, header+": "+strings.ToLower(r.Method)+" "+r.URL.RequestURI())
+			continue
+		}
+		lines = append(lines, header+": "+r.Header.Get(header))
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.APISecret))
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	expected := mac.Sum(nil)
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil || !hmac.Equal(signature, expected) {
+		return newAPIError(http.StatusForbidden, "NotAllowedException", "the http signature is invalid")
+	}
+	return nil
+}
+
+// parseSignatureParams reads the key="value" pairs of a Signature authorization header
+func parseSignatureParams(header string) map[string]string {
+	params := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		params[parts[0]] = strings.Trim(parts[1], `"`)
+	}
+	return params
+}
@@ -0,0 +1,98 @@
+package getstreamtest_test
+
+import (
+	"net/http"
+	"testing"
+
+	getstream "github.com/GetStream/stream-go"
+	"github.com/GetStream/stream-go/getstreamtest"
+)
+
+func TestServerRejectsWrongSecret(t *testing.T) {
+	server := getstreamtest.NewServer("key", "secret")
+	defer server.Close()
+
+	cfg := server.Config()
+	cfg.APISecret = "wrong"
+	client, err := server.NewClientWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, _ := client.FlatFeed("user", "bob")
+
+	// feed token
+	_, err = feed.Activities(nil)
+	if !getstream.IsAuthFailed(err) {
+		t.Error("expected the feed token to be rejected, got:", err)
+	}
+
+	// http signature
+	err = client.AddActivityToMany(getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:1"}, []string{"user:bob"})
+	if !getstream.IsAuthFailed(err) {
+		t.Error("expected the http signature to be rejected, got:", err)
+	}
+
+	// jwt
+	_, err = feed.FollowStats()
+	if !getstream.IsAuthFailed(err) {
+		t.Error("expected the jwt to be rejected, got:", err)
+	}
+}
+
+func TestServerRejectsWrongAPIKey(t *testing.T) {
+	server := getstreamtest.NewServer("key", "secret")
+	defer server.Close()
+
+	cfg := server.Config()
+	cfg.APIKey = "wrong"
+	client, err := server.NewClientWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, _ := client.FlatFeed("user", "bob")
+	_, err = feed.Activities(nil)
+	if !getstream.IsAuthFailed(err) {
+		t.Error("expected the api key to be rejected, got:", err)
+	}
+}
+
+func TestServerJWTScope(t *testing.T) {
+	server := getstreamtest.NewServer("key", "secret")
+	defer server.Close()
+
+	signer := getstream.Signer{Secret: "secret"}
+
+	for _, test := range []struct {
+		context getstream.ScopeContext
+		action  getstream.ScopeAction
+		feed    string
+		status  int
+	}{
+		{getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob", http.StatusOK},
+		{getstream.ScopeContextAll, getstream.ScopeActionAll, "", http.StatusOK},
+		{getstream.ScopeContextFeed, getstream.ScopeActionRead, "useralice", http.StatusForbidden},
+		{getstream.ScopeContextFeed, getstream.ScopeActionWrite, "userbob", http.StatusForbidden},
+		{getstream.ScopeContextActivities, getstream.ScopeActionRead, "userbob", http.StatusForbidden},
+	} {
+		token, err := signer.GenerateFeedScopeToken(test.context, test.action, test.feed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest("GET", server.URL()+"/api/v1.0/feed/user/bob/?api_key=key", nil)
+		req.Header.Set("stream-auth-type", "jwt")
+		req.Header.Set("Authorization", token)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.status {
+			t.Error(test.context.Value(), test.action.Value(), test.feed, "expected", test.status, "got", resp.StatusCode)
+		}
+	}
+}
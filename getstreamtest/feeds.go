@@ -0,0 +1,633 @@
+package getstreamtest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultLimit is the number of activities and groups returned when no limit is given
+const defaultLimit = 25
+
+// maxActivities is the number of activities the activities/ endpoint accepts in a request
+const maxActivities = 100
+
+// intParam reads an integer query param, def when it is not set
+func intParam(r *request, name string, def int) (int, *apiError) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return 0, newInputError(map[string][]string{
+			name: {"A valid integer is required."},
+		})
+	}
+	return i, nil
+}
+
+// addActivities handles POST feed/<slug>/<id>/, with a single activity or an "activities" list
+func (s *Server) addActivities(r *request, feed feedID) (map[string]interface{}, int, *apiError) {
+	var payload map[string]interface{}
+	if err := r.decode(&payload); err != nil {
+		return nil, 0, err
+	}
+
+	list, isList := payload["activities"].([]interface{})
+	if !isList {
+		result, err := s.addActivity(feed, payload)
+		if err != nil {
+			return nil, 0, err
+		}
+		return result, http.StatusCreated, nil
+	}
+
+	results := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, 0, newInputError(map[string][]string{
+				"activities": {"Expected a list of activities."},
+			})
+		}
+
+		result, err := s.addActivity(feed, fields)
+		if err != nil {
+			return nil, 0, err
+		}
+		results = append(results, result)
+	}
+	return map[string]interface{}{
+		"activities": results,
+	}, http.StatusCreated, nil
+}
+
+// addActivity adds an activity to a feed and to the feeds listed in its "to" field
+func (s *Server) addActivity(feed feedID, fields map[string]interface{}) (map[string]interface{}, *apiError) {
+	targets, err := s.parseTo(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := s.store.newActivity(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	s.store.add(feed, a)
+	for _, target := range targets {
+		s.store.add(target, a)
+	}
+
+	e := &entry{activity: a}
+	return e.render(), nil
+}
+
+// parseTo reads the "to" field of an activity, a list of "FeedSlug:UserID" feeds optionally followed by their token
+// the tokens are checked and left out of the stored activity
+func (s *Server) parseTo(fields map[string]interface{}) ([]feedID, *apiError) {
+	raw, ok := fields["to"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, newInputError(map[string][]string{
+			"to": {"Expected a list of feeds."},
+		})
+	}
+
+	var targets []feedID
+	var stored []interface{}
+	for _, item := range list {
+		value, _ := item.(string)
+		parts := strings.Fields(value)
+		if len(parts) == 0 || !feedID(parts[0]).valid() {
+			return nil, newInputError(map[string][]string{
+				"to": {"Invalid feed " + strconv.Quote(value) + "."},
+			})
+		}
+
+		target := feedID(parts[0])
+		if len(parts) > 1 && parts[1] != s.feedToken(target) {
+			return nil, newAPIError(http.StatusForbidden, "NotAllowedException", "the feed token is invalid for feed "+string(target))
+		}
+
+		targets = append(targets, target)
+		stored = append(stored, string(target))
+	}
+
+	fields["to"] = stored
+	return targets, nil
+}
+
+// addToMany handles POST feed/add_to_many/
+func (s *Server) addToMany(r *request) (map[string]interface{}, int, *apiError) {
+	var payload struct {
+		Activity map[string]interface{} `json:"activity"`
+		Feeds    []string               `json:"feeds"`
+	}
+	if err := r.decode(&payload); err != nil {
+		return nil, 0, err
+	}
+
+	if payload.Activity == nil {
+		return nil, 0, newInputError(map[string][]string{
+			"activity": {"This field is required."},
+		})
+	}
+	for _, feed := range payload.Feeds {
+		if !feedID(feed).valid() {
+			return nil, 0, newInputError(map[string][]string{
+				"feeds": {"Invalid feed " + strconv.Quote(feed) + "."},
+			})
+		}
+	}
+
+	a, err := s.store.newActivity(payload.Activity)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, feed := range payload.Feeds {
+		s.store.add(feedID(feed), a)
+	}
+	return nil, http.StatusCreated, nil
+}
+
+// removeActivity handles DELETE feed/<slug>/<id>/<activity_id>/
+// with foreign_id=1 the activities with the foreign_id are removed instead
+func (s *Server) removeActivity(r *request, feed feedID, id string) (map[string]interface{}, int, *apiError) {
+	byForeignID := r.URL.Query().Get("foreign_id") == "1"
+
+	s.store.remove(feed, func(a *activity) bool {
+		if byForeignID {
+			return a.field("foreign_id") == id
+		}
+		return a.id == id
+	})
+
+	return map[string]interface{}{
+		"removed": id,
+	}, http.StatusOK, nil
+}
+
+// updateActivities handles POST activities/, activities are matched by foreign_id and time
+func (s *Server) updateActivities(r *request) (map[string]interface{}, int, *apiError) {
+	var payload struct {
+		Activities []map[string]interface{} `json:"activities"`
+	}
+	if err := r.decode(&payload); err != nil {
+		return nil, 0, err
+	}
+
+	updates := make([]*activity, len(payload.Activities))
+	for i, fields := range payload.Activities {
+		foreignID, _ := fields["foreign_id"].(string)
+		time, _ := fields["time"].(string)
+		if foreignID == "" || time == "" {
+			return nil, 0, newInputError(map[string][]string{
+				"activities": {"Activities need a foreign_id and a time to be updated."},
+			})
+		}
+		time, ok := normalizeTime(time)
+		if !ok {
+			return nil, 0, newInputError(map[string][]string{"time": {"Datetime has wrong format."}})
+		}
+		fields["time"] = time
+
+		a, ok := s.store.foreignIDs[foreignID+"|"+time]
+		if !ok {
+			return nil, 0, newAPIError(http.StatusNotFound, "DoesNotExistException", "no activity with foreign_id "+foreignID+" and time "+time)
+		}
+		updates[i] = a
+	}
+
+	for i, a := range updates {
+		fields := payload.Activities[i]
+		delete(fields, "id")
+		if _, ok := fields["to"]; !ok {
+			fields["to"] = a.fields["to"]
+		}
+		a.fields = fields
+	}
+	return nil, http.StatusCreated, nil
+}
+
+// getActivities handles GET activities/, activities are read by id or by foreign_id and time
+// activities which do not exist are left out of the results
+func (s *Server) getActivities(r *request) (map[string]interface{}, int, *apiError) {
+	query := r.URL.Query()
+	ids, foreignIDs, timestamps := splitParam(query.Get("ids")), splitParam(query.Get("foreign_ids")), splitParam(query.Get("timestamps"))
+
+	var found []*activity
+	switch {
+	case len(ids) > 0 && len(foreignIDs) == 0 && len(timestamps) == 0:
+		if len(ids) > maxActivities {
+			return nil, 0, newInputError(map[string][]string{"ids": {"Too many activities."}})
+		}
+		for _, id := range ids {
+			if a, ok := s.store.activities[id]; ok {
+				found = append(found, a)
+			}
+		}
+
+	case len(ids) == 0 && len(foreignIDs) > 0 && len(foreignIDs) == len(timestamps):
+		if len(foreignIDs) > maxActivities {
+			return nil, 0, newInputError(map[string][]string{"foreign_ids": {"Too many activities."}})
+		}
+		for i, foreignID := range foreignIDs {
+			time, ok := normalizeTime(timestamps[i])
+			if !ok {
+				return nil, 0, newInputError(map[string][]string{"timestamps": {"Datetime has wrong format."}})
+			}
+			if a, ok := s.store.foreignIDs[foreignID+"|"+time]; ok {
+				found = append(found, a)
+			}
+		}
+
+	default:
+		return nil, 0, newInputError(map[string][]string{
+			"ids": {"Provide either ids, or foreign_ids and timestamps of the same length."},
+		})
+	}
+
+	results := make([]interface{}, 0, len(found))
+	for _, a := range found {
+		results = append(results, (&entry{activity: a}).render())
+	}
+	return map[string]interface{}{
+		"results": results,
+	}, http.StatusOK, nil
+}
+
+// partialUpdateActivities handles POST activity/, the changes set and unset fields of activities
+// matched by id or by foreign_id and time
+func (s *Server) partialUpdateActivities(r *request) (map[string]interface{}, int, *apiError) {
+	var payload struct {
+		Changes []struct {
+			ID        string                 `json:"id"`
+			ForeignID string                 `json:"foreign_id"`
+			Time      string                 `json:"time"`
+			Set       map[string]interface{} `json:"set"`
+			Unset     []string               `json:"unset"`
+		} `json:"changes"`
+	}
+	if err := r.decode(&payload); err != nil {
+		return nil, 0, err
+	}
+	if len(payload.Changes) == 0 || len(payload.Changes) > maxActivities {
+		return nil, 0, newInputError(map[string][]string{"changes": {"Provide between 1 and 100 changes."}})
+	}
+
+	updates := make([]*activity, len(payload.Changes))
+	for i, change := range payload.Changes {
+		var a *activity
+		var ok bool
+		switch {
+		case change.ID != "":
+			a, ok = s.store.activities[change.ID]
+		case change.ForeignID != "" && change.Time != "":
+			time, valid := normalizeTime(change.Time)
+			if !valid {
+				return nil, 0, newInputError(map[string][]string{"time": {"Datetime has wrong format."}})
+			}
+			a, ok = s.store.foreignIDs[change.ForeignID+"|"+time]
+		default:
+			return nil, 0, newInputError(map[string][]string{"id": {"Provide an id, or a foreign_id and a time."}})
+		}
+		if !ok {
+			return nil, 0, newAPIError(http.StatusNotFound, "DoesNotExistException", "the activity of change "+strconv.Itoa(i)+" does not exist")
+		}
+
+		for key := range change.Set {
+			if err := checkUpdatableField(key); err != nil {
+				return nil, 0, err
+			}
+			if err := checkPath(a.fields, key); err != nil {
+				return nil, 0, err
+			}
+			for other := range change.Set {
+				if strings.HasPrefix(other, key+".") {
+					return nil, 0, newInputError(map[string][]string{key: {"The field is set along with its nested field " + other + "."}})
+				}
+			}
+		}
+		for _, key := range change.Unset {
+			if err := checkUpdatableField(key); err != nil {
+				return nil, 0, err
+			}
+		}
+		updates[i] = a
+	}
+
+	results := make([]interface{}, len(updates))
+	for i, a := range updates {
+		change := payload.Changes[i]
+		for key, value := range change.Set {
+			setPath(a.fields, key, value)
+		}
+		for _, key := range change.Unset {
+			unsetPath(a.fields, key)
+		}
+		results[i] = (&entry{activity: a}).render()
+	}
+	return map[string]interface{}{
+		"activities": results,
+	}, http.StatusCreated, nil
+}
+
+// checkUpdatableField rejects partial updates of the fields identifying an activity
+func checkUpdatableField(key string) *apiError {
+	switch strings.SplitN(key, ".", 2)[0] {
+	case "", "id", "foreign_id", "time", "actor", "verb", "object", "to":
+		return newInputError(map[string][]string{key: {"This field cannot be updated."}})
+	}
+	return nil
+}
+
+// checkPath checks the fields along a dotted path are objects, or do not exist yet
+func checkPath(fields map[string]interface{}, path string) *apiError {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		if fields[key] == nil {
+			return nil
+		}
+		nested, ok := fields[key].(map[string]interface{})
+		if !ok {
+			return newInputError(map[string][]string{path: {"The path goes through a field which is not an object."}})
+		}
+		fields = nested
+	}
+	return nil
+}
+
+// setPath sets the value at a dotted path of fields, creating the objects along the path
+func setPath(fields map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := fields[key].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			fields[key] = nested
+		}
+		fields = nested
+	}
+	fields[keys[len(keys)-1]] = value
+}
+
+// unsetPath removes the value at a dotted path of fields, if it exists
+func unsetPath(fields map[string]interface{}, path string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := fields[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields = nested
+	}
+	delete(fields, keys[len(keys)-1])
+}
+
+// splitParam splits a comma separated query param, an empty param has no values
+func splitParam(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// readFeed handles GET feed/<slug>/<id>/
+func (s *Server) readFeed(r *request, feed feedID) (map[string]interface{}, int, *apiError) {
+	limit, err := intParam(r, "limit", defaultLimit)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if s.feedType(feed.slug()) == Flat {
+		return s.readFlatFeed(r, feed, limit, offset)
+	}
+	return s.readGroupedFeed(r, feed, limit, offset)
+}
+
+func (s *Server) readFlatFeed(r *request, feed feedID, limit int, offset int) (map[string]interface{}, int, *apiError) {
+	entries := s.store.feeds[feed]
+
+	kept, err := filterByID(r, len(entries), func(id string) (func(i int) int, bool) {
+		cursor, ok := s.store.activities[id]
+		return func(i int) int {
+			switch {
+			case entries[i].activity == cursor:
+				return 0
+			case entries[i].activity.newerThan(cursor):
+				return 1
+			}
+			return -1
+		}, ok
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, more := paginate(kept, limit, offset)
+	results := make([]interface{}, 0, len(page))
+	for _, i := range page {
+		results = append(results, entries[i].render())
+	}
+
+	next := ""
+	if more {
+		next = s.nextURL(r, entries[page[len(page)-1]].activity.id, limit)
+	}
+
+	return map[string]interface{}{
+		"results": results,
+		"next":    next,
+	}, http.StatusOK, nil
+}
+
+func (s *Server) readGroupedFeed(r *request, feed feedID, limit int, offset int) (map[string]interface{}, int, *apiError) {
+	groups := s.store.groups(feed)
+	notification := s.feedType(feed.slug()) == Notification
+
+	positions := make(map[string]int, len(groups))
+	for i, g := range groups {
+		positions[g.id] = i
+	}
+
+	kept, err := filterByID(r, len(groups), func(id string) (func(i int) int, bool) {
+		cursor, ok := positions[id]
+		return func(i int) int {
+			return cursor - i
+		}, ok
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, more := paginate(kept, limit, offset)
+	results := make([]interface{}, 0, len(page))
+	for _, i := range page {
+		results = append(results, s.renderGroup(feed, groups[i], notification))
+	}
+
+	next := ""
+	if more {
+		next = s.nextURL(r, groups[page[len(page)-1]].id, limit)
+	}
+
+	result := map[string]interface{}{
+		"results": results,
+		"next":    next,
+	}
+
+	if notification {
+		// counts and marks apply to the whole feed, the response reflects the state before marking
+		unread, unseen := 0, 0
+		for _, g := range groups {
+			state := s.store.state(feed, g)
+			if !state.read {
+				unread++
+			}
+			if !state.seen {
+				unseen++
+			}
+		}
+		result["unread"] = unread
+		result["unseen"] = unseen
+
+		markGroups(groups, r.URL.Query().Get("mark_read"), func(g *group) {
+			s.store.state(feed, g).read = true
+		})
+		markGroups(groups, r.URL.Query().Get("mark_seen"), func(g *group) {
+			s.store.state(feed, g).seen = true
+		})
+	}
+
+	return result, http.StatusOK, nil
+}
+
+// renderGroup returns an aggregation group as it is sent in responses
+func (s *Server) renderGroup(feed feedID, g *group, notification bool) map[string]interface{} {
+	activities := make([]interface{}, 0, len(g.entries))
+	actors := make(map[string]bool)
+	for _, e := range g.entries {
+		activities = append(activities, e.render())
+		actors[e.activity.field("actor")] = true
+	}
+
+	result := map[string]interface{}{
+		"id":             g.id,
+		"group":          g.key,
+		"verb":           g.entries[0].activity.field("verb"),
+		"activities":     activities,
+		"activity_count": len(g.entries),
+		"actor_count":    len(actors),
+		"created_at":     g.entries[len(g.entries)-1].activity.field("time"),
+		"updated_at":     g.entries[0].activity.field("time"),
+	}
+
+	if notification {
+		state := s.store.state(feed, g)
+		result["is_read"] = state.read
+		result["is_seen"] = state.seen
+	}
+	return result
+}
+
+// markGroups calls mark for the groups listed in a mark_read or mark_seen param
+// the param is "true" for every group, or a comma separated list of group ids or of ids of activities in a group
+func markGroups(groups []*group, param string, mark func(g *group)) {
+	if param == "" {
+		return
+	}
+
+	ids := make(map[string]bool)
+	for _, id := range strings.Split(param, ",") {
+		ids[strings.TrimSpace(id)] = true
+	}
+
+	for _, g := range groups {
+		if param == "true" || ids[g.id] {
+			mark(g)
+			continue
+		}
+		for _, e := range g.entries {
+			if ids[e.activity.id] {
+				mark(g)
+				break
+			}
+		}
+	}
+}
+
+// filterByID applies the id_lt, id_lte, id_gt and id_gte params to a list ordered newest first
+// it returns the indexes of the items to keep; cursor returns how the item at an index compares
+// with the item of an id: > 0 when it is newer, 0 when it is the item itself
+func filterByID(r *request, length int, cursor func(id string) (compare func(i int) int, ok bool)) ([]int, *apiError) {
+	kept := make([]int, length)
+	for i := range kept {
+		kept[i] = i
+	}
+
+	filters := []struct {
+		param string
+		keep  func(cmp int) bool
+	}{
+		{"id_lt", func(cmp int) bool { return cmp < 0 }},
+		{"id_lte", func(cmp int) bool { return cmp <= 0 }},
+		{"id_gt", func(cmp int) bool { return cmp > 0 }},
+		{"id_gte", func(cmp int) bool { return cmp >= 0 }},
+	}
+
+	for _, filter := range filters {
+		id := r.URL.Query().Get(filter.param)
+		if id == "" {
+			continue
+		}
+
+		compare, ok := cursor(id)
+		if !ok {
+			return nil, newInputError(map[string][]string{
+				filter.param: {"Unknown id " + strconv.Quote(id) + "."},
+			})
+		}
+
+		var filtered []int
+		for _, i := range kept {
+			if filter.keep(compare(i)) {
+				filtered = append(filtered, i)
+			}
+		}
+		kept = filtered
+	}
+	return kept, nil
+}
+
+// paginate returns the page of a list of indexes, and whether indexes remain after it
+func paginate(indexes []int, limit int, offset int) (page []int, more bool) {
+	if offset >= len(indexes) {
+		return nil, false
+	}
+	page = indexes[offset:]
+	if len(page) > limit {
+		return page[:limit], limit > 0
+	}
+	return page, false
+}
+
+// nextURL is the url of the next page, starting after the item with the given id
+func (s *Server) nextURL(r *request, lastID string, limit int) string {
+	query := url.Values{}
+	query.Set("api_key", s.APIKey)
+	query.Set("id_lt", lastID)
+	query.Set("limit", strconv.Itoa(limit))
+	return r.URL.Path + "?" + query.Encode()
+}
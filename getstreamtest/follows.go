@@ -0,0 +1,172 @@
+package getstreamtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultCopyLimit is the number of activities copied on follow when no activity_copy_limit is given
+const defaultCopyLimit = 300
+
+// follow handles POST feed/<slug>/<id>/following/
+func (s *Server) follow(r *request, feed feedID) (map[string]interface{}, int, *apiError) {
+	var payload struct {
+		Target            string       `json:"target"`
+		ActivityCopyLimit *json.Number `json:"activity_copy_limit"`
+	}
+	if err := r.decode(&payload); err != nil {
+		return nil, 0, err
+	}
+
+	copyLimit := defaultCopyLimit
+	if payload.ActivityCopyLimit != nil {
+		limit, err := payload.ActivityCopyLimit.Int64()
+		if err != nil || limit < 0 {
+			return nil, 0, newInputError(map[string][]string{
+				"activity_copy_limit": {"A valid integer is required."},
+			})
+		}
+		copyLimit = int(limit)
+	}
+
+	if err := validateFollow(feed, feedID(payload.Target)); err != nil {
+		return nil, 0, err
+	}
+
+	s.store.follow(feed, feedID(payload.Target), copyLimit)
+	return nil, http.StatusCreated, nil
+}
+
+// validateFollow checks source can follow target
+func validateFollow(source feedID, target feedID) *apiError {
+	if !target.valid() {
+		return newInputError(map[string][]string{
+			"target": {"Invalid feed " + strconv.Quote(string(target)) + "."},
+		})
+	}
+	if source == target {
+		return newInputError(map[string][]string{
+			"target": {"A feed cannot follow itself."},
+		})
+	}
+	return nil
+}
+
+// unfollow handles DELETE feed/<slug>/<id>/following/<target>/
+func (s *Server) unfollow(r *request, feed feedID, target feedID) (map[string]interface{}, int, *apiError) {
+	keepHistory := r.URL.Query().Get("keep_history")
+	s.store.unfollow(feed, target, keepHistory == "1" || strings.EqualFold(keepHistory, "true"))
+	return nil, http.StatusOK, nil
+}
+
+// followMany handles POST follow_many/
+func (s *Server) followMany(r *request) (map[string]interface{}, int, *apiError) {
+	var payload []struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+	if err := r.decode(&payload); err != nil {
+		return nil, 0, err
+	}
+
+	copyLimit, err := intParam(r, "activity_copy_limit", defaultCopyLimit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, follow := range payload {
+		if !feedID(follow.Source).valid() {
+			return nil, 0, newInputError(map[string][]string{
+				"source": {"Invalid feed " + strconv.Quote(follow.Source) + "."},
+			})
+		}
+		if err := validateFollow(feedID(follow.Source), feedID(follow.Target)); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	for _, follow := range payload {
+		s.store.follow(feedID(follow.Source), feedID(follow.Target), copyLimit)
+	}
+	return nil, http.StatusCreated, nil
+}
+
+// followers handles GET feed/<slug>/<id>/followers/
+func (s *Server) followers(r *request, feed feedID) (map[string]interface{}, int, *apiError) {
+	return s.follows(r, s.store.followers(feed))
+}
+
+// following handles GET feed/<slug>/<id>/following/
+func (s *Server) following(r *request, feed feedID) (map[string]interface{}, int, *apiError) {
+	return s.follows(r, s.store.following(feed))
+}
+
+// follows returns a page of follow relationships
+func (s *Server) follows(r *request, follows []*follow) (map[string]interface{}, int, *apiError) {
+	limit, err := intParam(r, "limit", defaultLimit)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]interface{}, 0, limit)
+	for i := offset; i < len(follows) && len(results) < limit; i++ {
+		results = append(results, follows[i].render())
+	}
+
+	return map[string]interface{}{
+		"results": results,
+	}, http.StatusOK, nil
+}
+
+// followStats handles GET stats/follow/
+// followers_slugs and following_slugs restrict the counts to feeds of the listed groups
+func (s *Server) followStats(r *request) (map[string]interface{}, int, *apiError) {
+	query := r.URL.Query()
+
+	count := func(follows []*follow, slugsParam string, other func(f *follow) feedID) int {
+		slugs := make(map[string]bool)
+		for _, slug := range strings.Split(query.Get(slugsParam), ",") {
+			if slug != "" {
+				slugs[slug] = true
+			}
+		}
+
+		n := 0
+		for _, f := range follows {
+			if len(slugs) == 0 || slugs[other(f).slug()] {
+				n++
+			}
+		}
+		return n
+	}
+
+	results := make(map[string]interface{})
+	if feed := feedID(query.Get("followers")); feed != "" {
+		results["followers"] = map[string]interface{}{
+			"feed":  string(feed),
+			"count": count(s.store.followers(feed), "followers_slugs", func(f *follow) feedID { return f.source }),
+		}
+	}
+	if feed := feedID(query.Get("following")); feed != "" {
+		results["following"] = map[string]interface{}{
+			"feed":  string(feed),
+			"count": count(s.store.following(feed), "following_slugs", func(f *follow) feedID { return f.target }),
+		}
+	}
+
+	if len(results) == 0 {
+		return nil, 0, newInputError(map[string][]string{
+			"followers": {"Either followers or following is required."},
+		})
+	}
+
+	return map[string]interface{}{
+		"results": results,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,308 @@
+// Package getstreamtest provides an in-memory stand-in for the getstream.io API, for tests which should not
+// depend on a network connection or on credentials of a real application.
+//
+//	server := getstreamtest.NewServer("key", "secret")
+//	defer server.Close()
+//
+//	client, err := server.NewClient()
+//
+// The Server implements the feed, follow, follow_many, add_to_many, activities, activity partial update,
+// mark read/seen and aggregation endpoints, and checks the feed tokens, JWTs and http signatures of requests
+// the same way the API does.
+package getstreamtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+// FeedType is the kind of feed group, it decides how the activities of a feed are returned
+type FeedType int
+
+const (
+	// Flat feeds return their activities as a list
+	Flat FeedType = iota
+	// Aggregated feeds return their activities in groups
+	Aggregated
+	// Notification feeds return their activities in groups, with read and seen state
+	Notification
+)
+
+// Server is an in-memory stand-in for the getstream.io API
+type Server struct {
+	APIKey    string
+	APISecret string
+
+	server *httptest.Server
+
+	mu        sync.Mutex
+	feedTypes map[string]FeedType
+	store     *store
+}
+
+// NewServer starts a Server accepting requests signed with the given credentials
+// The feed groups "aggregated" and "notification" are set up with their matching FeedType, every other group is Flat
+func NewServer(apiKey string, apiSecret string) *Server {
+	s := &Server{
+		APIKey:    apiKey,
+		APISecret: apiSecret,
+		feedTypes: map[string]FeedType{
+			"aggregated":   Aggregated,
+			"notification": Notification,
+		},
+		store: newStore(),
+	}
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// URL is the root url of the server, like http://127.0.0.1:1234
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// BaseURL is the url of the API on the server, to be used as the BaseURL of a Client
+func (s *Server) BaseURL() *url.URL {
+	baseURL, _ := url.Parse(s.server.URL + "/api/v1.0/")
+	return baseURL
+}
+
+// Config returns a Config with the credentials of the server
+func (s *Server) Config() *getstream.Config {
+	return &getstream.Config{
+		APIKey:    s.APIKey,
+		APISecret: s.APISecret,
+		AppID:     "1",
+		BaseURL:   s.BaseURL(),
+	}
+}
+
+// NewClient returns a Client talking to the server with its credentials, configured by the options
+func (s *Server) NewClient(opts ...getstream.Option) (*getstream.Client, error) {
+	opts = append([]getstream.Option{getstream.WithAppID("1"), getstream.WithBaseURL(s.BaseURL())}, opts...)
+	return getstream.NewClient(s.APIKey, s.APISecret, opts...)
+}
+
+// NewClientWithConfig returns a Client talking to the server, built from a copy of cfg with the BaseURL of the server
+func (s *Server) NewClientWithConfig(cfg *getstream.Config) (*getstream.Client, error) {
+	clientCfg := *cfg
+	clientCfg.BaseURL = s.BaseURL()
+	return getstream.New(&clientCfg)
+}
+
+// SetFeedType sets the FeedType of a feed group
+func (s *Server) SetFeedType(feedSlug string, feedType FeedType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.feedTypes[feedSlug] = feedType
+}
+
+// Reset removes every activity and follow relationship
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = newStore()
+}
+
+func (s *Server) feedType(feedSlug string) FeedType {
+	return s.feedTypes[feedSlug]
+}
+
+// request is an API request being handled
+type request struct {
+	*http.Request
+	start    time.Time
+	segments []string // the path after /api/<version>/, split on "/"
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &request{
+		Request: r,
+		start:   time.Now(),
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.SplitN(path, "/", 3)
+	if len(parts) < 3 || parts[0] != "api" {
+		s.writeError(w, req, newAPIError(http.StatusNotFound, "DoesNotExistException", "unknown url "+r.URL.Path))
+		return
+	}
+	req.segments = strings.Split(parts[2], "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, status, apiErr := s.route(req)
+	if apiErr != nil {
+		s.writeError(w, req, apiErr)
+		return
+	}
+	s.write(w, req, status, result)
+}
+
+// route authenticates the request and hands it to the handler of its endpoint
+func (s *Server) route(r *request) (map[string]interface{}, int, *apiError) {
+	if r.URL.Query().Get("api_key") != s.APIKey {
+		return nil, 0, newAPIError(http.StatusUnauthorized, "AuthenticationFailed", "api_key is missing or invalid")
+	}
+
+	segments := r.segments
+	switch {
+	case len(segments) == 1 && segments[0] == "follow_many" && r.Method == http.MethodPost:
+		if err := s.authenticateApp(r); err != nil {
+			return nil, 0, err
+		}
+		return s.followMany(r)
+
+	case len(segments) == 1 && segments[0] == "activities" && r.Method == http.MethodPost:
+		if err := s.authenticate(r, "", getstream.ScopeContextActivities); err != nil {
+			return nil, 0, err
+		}
+		return s.updateActivities(r)
+
+	case len(segments) == 1 && segments[0] == "activities" && r.Method == http.MethodGet:
+		if err := s.authenticate(r, "", getstream.ScopeContextActivities); err != nil {
+			return nil, 0, err
+		}
+		return s.getActivities(r)
+
+	case len(segments) == 1 && segments[0] == "activity" && r.Method == http.MethodPost:
+		if err := s.authenticate(r, "", getstream.ScopeContextActivities); err != nil {
+			return nil, 0, err
+		}
+		return s.partialUpdateActivities(r)
+
+	case len(segments) == 2 && segments[0] == "feed" && segments[1] == "add_to_many" && r.Method == http.MethodPost:
+		if err := s.authenticateApp(r); err != nil {
+			return nil, 0, err
+		}
+		return s.addToMany(r)
+
+	case len(segments) == 2 && segments[0] == "stats" && segments[1] == "follow" && r.Method == http.MethodGet:
+		if err := s.authenticate(r, "", getstream.ScopeContextFollower); err != nil {
+			return nil, 0, err
+		}
+		return s.followStats(r)
+
+	case len(segments) >= 3 && segments[0] == "feed":
+		return s.routeFeed(r, feedID(segments[1]+":"+segments[2]), segments[3:])
+	}
+
+	return nil, 0, newAPIError(http.StatusNotFound, "DoesNotExistException", "unknown endpoint "+r.Method+" "+r.URL.Path)
+}
+
+// routeFeed hands a request for the feed/<slug>/<id>/ endpoints to its handler
+func (s *Server) routeFeed(r *request, feed feedID, segments []string) (map[string]interface{}, int, *apiError) {
+	resource := getstream.ScopeContextFeed
+	if len(segments) > 0 && (segments[0] == "followers" || segments[0] == "following") {
+		resource = getstream.ScopeContextFollower
+	}
+	if err := s.authenticate(r, feed, resource); err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		return s.readFeed(r, feed)
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		return s.addActivities(r, feed)
+	case len(segments) == 1 && segments[0] == "followers" && r.Method == http.MethodGet:
+		return s.followers(r, feed)
+	case len(segments) == 1 && segments[0] == "following" && r.Method == http.MethodGet:
+		return s.following(r, feed)
+	case len(segments) == 1 && segments[0] == "following" && r.Method == http.MethodPost:
+		return s.follow(r, feed)
+	case len(segments) == 2 && segments[0] == "following" && r.Method == http.MethodDelete:
+		return s.unfollow(r, feed, feedID(segments[1]))
+	case len(segments) == 1 && r.Method == http.MethodDelete:
+		return s.removeActivity(r, feed, segments[0])
+	}
+
+	return nil, 0, newAPIError(http.StatusNotFound, "DoesNotExistException", "unknown endpoint "+r.Method+" "+r.URL.Path)
+}
+
+// decode reads the JSON body of a request, numbers are kept as json.Number
+func (r *request) decode(v interface{}) *apiError {
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return newAPIError(http.StatusBadRequest, "InputException", "invalid json payload: "+err.Error())
+	}
+	return nil
+}
+
+// duration is the time spent handling a request, formatted like the API does
+func (r *request) duration() string {
+	return fmt.Sprintf("%.2fms", float64(time.Since(r.start))/float64(time.Millisecond))
+}
+
+func (s *Server) write(w http.ResponseWriter, r *request, status int, result map[string]interface{}) {
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	result["duration"] = r.duration()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+// apiError is an error response, encoded like the errors of the API
+type apiError struct {
+	StatusCode      int                 `json:"status_code"`
+	Code            int                 `json:"code"`
+	Exception       string              `json:"exception"`
+	Detail          string              `json:"detail"`
+	Duration        string              `json:"duration"`
+	ExceptionFields map[string][]string `json:"exception_fields,omitempty"`
+}
+
+// errorCodes are the numeric codes the API sends along with an exception
+var errorCodes = map[string]int{
+	"InputException":         4,
+	"AuthenticationFailed":   5,
+	"DoesNotExistException":  16,
+	"NotAllowedException":    17,
+	"RateLimitReached":       9,
+	"SiteSuspendedException": 11,
+}
+
+func newAPIError(statusCode int, exception string, detail string) *apiError {
+	return &apiError{
+		StatusCode: statusCode,
+		Code:       errorCodes[exception],
+		Exception:  exception,
+		Detail:     detail,
+	}
+}
+
+// newInputError is a 400 response listing the invalid fields of the payload
+func newInputError(fields map[string][]string) *apiError {
+	err := newAPIError(http.StatusBadRequest, "InputException", "Errors for fields '"+strings.Join(sortedKeys(fields), "', '")+"'")
+	err.ExceptionFields = fields
+	return err
+}
+
+func (s *Server) writeError(w http.ResponseWriter, r *request, err *apiError) {
+	err.Duration = r.duration()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.StatusCode)
+	json.NewEncoder(w).Encode(err)
+}
@@ -0,0 +1,370 @@
+package getstreamtest_test
+
+import (
+	"testing"
+	"time"
+
+	getstream "github.com/GetStream/stream-go"
+	"github.com/GetStream/stream-go/getstreamtest"
+)
+
+func newTestClient(t *testing.T) (*getstreamtest.Server, *getstream.Client) {
+	server := getstreamtest.NewServer("key", "secret")
+
+	client, err := server.NewClient()
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return server, client
+}
+
+func TestServerAddAndReadActivities(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	feed, _ := client.FlatFeed("user", "bob")
+
+	added, err := feed.AddActivities([]*getstream.Activity{
+		{Actor: "user:bob", Verb: "post", Object: "post:1", ForeignID: "post:1"},
+		{Actor: "user:bob", Verb: "post", Object: "post:2", ForeignID: "post:2"},
+		{Actor: "user:bob", Verb: "like", Object: "post:3", ForeignID: "post:3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 3 || added[0].ID == "" {
+		t.Fatal("expected the activities to be returned with an id, got:", added)
+	}
+
+	var objects string
+	it := feed.Iterate(&getstream.GetFlatFeedInput{Limit: 2})
+	for it.Next() {
+		objects += it.Activity().Object + " "
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if objects != "post:3 post:2 post:1 " {
+		t.Error("expected the activities newest first, got:", objects)
+	}
+
+	err = feed.RemoveActivityByForeignID(added[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = feed.RemoveActivity(added[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := feed.Activities(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Activities) != 1 || output.Activities[0].ID != added[0].ID {
+		t.Error("expected a single activity to remain, got:", output.Activities)
+	}
+}
+
+func TestServerActivityValidation(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	feed, _ := client.FlatFeed("user", "bob")
+
+	_, err := feed.AddActivity(&getstream.Activity{Actor: "user:bob", Verb: "post"})
+	if !getstream.IsInputInvalid(err) {
+		t.Fatal("expected an invalid input error, got:", err)
+	}
+
+	apiErr := err.(*getstream.Error)
+	if fields := apiErr.ExceptionFields(); len(fields["object"]) == 0 {
+		t.Error("expected the object field to be reported, got:", fields)
+	}
+}
+
+func TestServerFollowCopiesAndFansOut(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	bob, _ := client.FlatFeed("user", "bob")
+	timeline, _ := client.FlatFeed("timeline", "alice")
+
+	_, err := bob.AddActivity(&getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = timeline.FollowFeedWithCopyLimit(bob, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = bob.AddActivity(&getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := timeline.Activities(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Activities) != 2 {
+		t.Fatal("expected the copied and the fanned out activity, got:", output.Activities)
+	}
+	if output.Activities[0].Origin != "user:bob" {
+		t.Error("expected the origin of the copy to be set, got:", output.Activities[0].Origin)
+	}
+
+	followers, err := bob.FollowersWithLimitAndSkip(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followers) != 1 || followers[0].FeedID() != "timeline:alice" {
+		t.Error("unexpected followers:", followers)
+	}
+
+	stats, err := bob.FollowStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Followers != 1 || stats.Following != 0 {
+		t.Error("unexpected follow stats:", stats)
+	}
+
+	err = timeline.UnfollowKeepingHistory(bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, _ = timeline.Activities(nil)
+	if len(output.Activities) != 2 {
+		t.Error("expected the history to be kept, got:", output.Activities)
+	}
+
+	timeline.FollowFeedWithCopyLimit(bob, 0)
+	err = timeline.Unfollow(bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, _ = timeline.Activities(nil)
+	if len(output.Activities) != 0 {
+		t.Error("expected the copied activities to be removed, got:", output.Activities)
+	}
+}
+
+func TestServerFollowManyAndAddToMany(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	bob, _ := client.FlatFeed("user", "bob")
+	sally, _ := client.FlatFeed("user", "sally")
+	timeline, _ := client.FlatFeed("timeline", "bob")
+
+	err := timeline.FollowManyFeeds([]getstream.PostFlatFeedFollowingManyInput{
+		{Source: "timeline:bob", Target: "user:sally"},
+	}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.AddActivityToMany(getstream.Activity{Actor: "user:eric", Verb: "post", Object: "post:1"}, []string{
+		string(bob.FeedID()), string(sally.FeedID()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, feed := range []*getstream.FlatFeed{bob, sally, timeline} {
+		output, err := feed.Activities(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(output.Activities) != 1 {
+			t.Error("expected the activity in", feed.FeedID(), "got:", output.Activities)
+		}
+	}
+}
+
+func TestServerUpdateActivities(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	feed, _ := client.FlatFeed("user", "bob")
+
+	activity, err := feed.AddActivity(&getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:1", ForeignID: "post:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	activity.Object = "post:2"
+	err = feed.UpdateActivity(activity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, _ := feed.Activities(nil)
+	if len(output.Activities) != 1 || output.Activities[0].Object != "post:2" {
+		t.Error("expected the activity to be updated, got:", output.Activities)
+	}
+
+	now := time.Now()
+	err = feed.UpdateActivity(&getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:1", ForeignID: "post:1", TimeStamp: &now})
+	if !getstream.IsNotFound(err) {
+		t.Error("expected an unknown foreign_id and time to be rejected, got:", err)
+	}
+}
+
+func TestServerActivityTime(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	feed, _ := client.FlatFeed("flat", "bob")
+
+	local := time.Date(2017, 1, 2, 3, 4, 5, 6000, time.FixedZone("UTC+2", 2*60*60))
+	activity, err := feed.AddActivity(&getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:1", ForeignID: "post:1", TimeStamp: &local})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !activity.TimeStamp.Equal(local) || activity.TimeStamp.Location() != time.UTC {
+		t.Error("expected the time to be stored in UTC, got", activity.TimeStamp)
+	}
+
+	utc := local.UTC()
+	err = feed.UpdateActivity(&getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:2", ForeignID: "post:1", TimeStamp: &utc})
+	if err != nil {
+		t.Error("expected the same time in another zone to match the activity, got:", err)
+	}
+
+	activity, err = feed.AddActivity(&getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:3", ServerTime: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activity.TimeStamp == nil || time.Since(*activity.TimeStamp) > time.Minute {
+		t.Error("expected the server to assign the time, got", activity.TimeStamp)
+	}
+}
+
+func TestServerAggregatedFeed(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	feed, _ := client.AggregatedFeed("aggregated", "bob")
+
+	_, err := feed.AddActivities([]*getstream.Activity{
+		{Actor: "user:bob", Verb: "post", Object: "post:1"},
+		{Actor: "user:eric", Verb: "post", Object: "post:2"},
+		{Actor: "user:bob", Verb: "like", Object: "post:3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := feed.Activities(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Results) != 2 {
+		t.Fatal("expected two groups, got:", len(output.Results))
+	}
+
+	group := output.Results[1]
+	if group.Verb != "post" || group.ActivityCount != 2 || group.ActorCount != 2 || len(group.Activities) != 2 {
+		t.Error("unexpected group:", group)
+	}
+}
+
+func TestServerNotificationFeed(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	feed, _ := client.NotificationFeed("notification", "bob")
+
+	_, err := feed.AddActivities([]*getstream.Activity{
+		{Actor: "user:bob", Verb: "post", Object: "post:1"},
+		{Actor: "user:bob", Verb: "like", Object: "post:2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := feed.Activities(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Unread != 2 || output.Unseen != 2 {
+		t.Fatal("expected every group to be unread and unseen, got:", output.Unread, output.Unseen)
+	}
+
+	err = feed.MarkActivitiesAsRead(output.Results[0].Activities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = feed.MarkActivitiesAsSeenWithLimit(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, _ = feed.Activities(nil)
+	if output.Unread != 1 || output.Unseen != 0 {
+		t.Error("unexpected unread and unseen counts:", output.Unread, output.Unseen)
+	}
+	if !output.Results[0].IsRead || output.Results[1].IsRead {
+		t.Error("expected the first group only to be read")
+	}
+
+	_, err = feed.AddActivity(&getstream.Activity{Actor: "user:eric", Verb: "like", Object: "post:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, _ = feed.Activities(nil)
+	if output.Unread != 2 || output.Unseen != 1 {
+		t.Error("expected a new activity to make its group unread and unseen, got:", output.Unread, output.Unseen)
+	}
+}
+
+func TestServerReset(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	feed, _ := client.FlatFeed("user", "bob")
+	feed.AddActivity(&getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:1"})
+
+	server.Reset()
+
+	output, err := feed.Activities(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Activities) != 0 {
+		t.Error("expected no activities after a reset, got:", output.Activities)
+	}
+}
+
+func TestServerPartialUpdateErrors(t *testing.T) {
+	server, client := newTestClient(t)
+	defer server.Close()
+
+	feed, _ := client.FlatFeed("flat", "bob")
+	activity, err := feed.AddActivity(&getstream.Activity{Actor: "user:bob", Verb: "post", Object: "post:1", Extra: map[string]interface{}{"score": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, set := range []map[string]interface{}{
+		{"actor": "user:eric"},
+		{"time": "2017-01-02T03:04:05"},
+		{"score.value": 2},
+		{"stats": map[string]interface{}{}, "stats.likes": 1},
+	} {
+		_, err = client.PartialUpdateActivity(getstream.UpdateActivityRequest{ID: activity.ID, Set: set})
+		if !getstream.IsInputInvalid(err) {
+			t.Error(set, "expected the update to be rejected, got:", err)
+		}
+	}
+
+	_, err = client.PartialUpdateActivity(getstream.UpdateActivityRequest{ID: "unknown", Set: map[string]interface{}{"score": 2}})
+	if !getstream.IsNotFound(err) {
+		t.Error("expected an unknown activity to be rejected, got:", err)
+	}
+}
@@ -0,0 +1,373 @@
+package getstreamtest
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timeLayout is the layout of activity times in the API
+const timeLayout = "2006-01-02T15:04:05.000000"
+
+// requestTimeLayouts are the layouts of the activity times accepted in requests, times without a zone are in UTC
+var requestTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// normalizeTime formats an activity time of a request with timeLayout in UTC, like the API stores it
+func normalizeTime(value string) (string, bool) {
+	for _, layout := range requestTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Format(timeLayout), true
+		}
+	}
+	return "", false
+}
+
+// feedID is a "FeedSlug:UserID" feed id
+type feedID string
+
+func (f feedID) parts() []string {
+	return strings.SplitN(string(f), ":", 2)
+}
+
+// valid reports if the feed id has a slug and a user id
+func (f feedID) valid() bool {
+	parts := f.parts()
+	return len(parts) == 2 && parts[0] != "" && parts[1] != "" && !strings.ContainsAny(string(f), " /")
+}
+
+func (f feedID) slug() string {
+	return f.parts()[0]
+}
+
+func (f feedID) withoutColon() string {
+	return strings.Replace(string(f), ":", "", 1)
+}
+
+// activity is stored once and shared by every feed it was added or copied to,
+// so updates are visible everywhere like they are in the API
+type activity struct {
+	id     string
+	seq    int
+	fields map[string]interface{}
+}
+
+func (a *activity) field(name string) string {
+	value, _ := a.fields[name].(string)
+	return value
+}
+
+// newerThan orders activities newest first, by time and then by the order they were added in
+func (a *activity) newerThan(b *activity) bool {
+	if at, bt := a.field("time"), b.field("time"); at != bt {
+		return at > bt
+	}
+	return a.seq > b.seq
+}
+
+// groupKey is the aggregation group of the activity, the default aggregation format of the API:
+// {{ verb.id }}_{{ time.strftime('%Y-%m-%d') }}
+func (a *activity) groupKey() string {
+	day := a.field("time")
+	if len(day) > 10 {
+		day = day[:10]
+	}
+	return a.field("verb") + "_" + day
+}
+
+// entry is an activity in a feed
+type entry struct {
+	activity *activity
+	origin   feedID // the followed feed the activity was copied from, empty when it was added to the feed itself
+}
+
+// render returns the activity as it is sent in responses
+func (e *entry) render() map[string]interface{} {
+	result := make(map[string]interface{}, len(e.activity.fields)+1)
+	for key, value := range e.activity.fields {
+		result[key] = value
+	}
+	result["id"] = e.activity.id
+	if e.origin != "" {
+		result["origin"] = string(e.origin)
+	}
+	return result
+}
+
+// follow is a follow relationship, source follows target
+type follow struct {
+	source    feedID
+	target    feedID
+	createdAt time.Time
+}
+
+func (f *follow) render() map[string]interface{} {
+	createdAt := f.createdAt.UTC().Format(timeLayout)
+	return map[string]interface{}{
+		"feed_id":    string(f.source),
+		"target_id":  string(f.target),
+		"created_at": createdAt,
+		"updated_at": createdAt,
+	}
+}
+
+// groupState is the read and seen state of a notification group
+type groupState struct {
+	read bool
+	seen bool
+}
+
+// store keeps the activities and follow relationships of the server
+type store struct {
+	seq        int
+	activities map[string]*activity
+	foreignIDs map[string]*activity // keyed by foreign_id and time
+	feeds      map[feedID][]*entry
+	follows    []*follow
+	states     map[feedID]map[string]*groupState // keyed by group key
+}
+
+func newStore() *store {
+	return &store{
+		activities: make(map[string]*activity),
+		foreignIDs: make(map[string]*activity),
+		feeds:      make(map[feedID][]*entry),
+		states:     make(map[feedID]map[string]*groupState),
+	}
+}
+
+// newActivity stores an activity from a request payload
+// like the API, an activity with the foreign_id and time of a stored activity is the stored activity
+func (s *store) newActivity(fields map[string]interface{}) (*activity, *apiError) {
+	invalid := make(map[string][]string)
+	for _, field := range []string{"actor", "verb", "object"} {
+		if value, _ := fields[field].(string); value == "" {
+			invalid[field] = []string{"This field is required."}
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, newInputError(invalid)
+	}
+
+	if value, _ := fields["time"].(string); value == "" {
+		fields["time"] = time.Now().UTC().Format(timeLayout)
+	} else if normalized, ok := normalizeTime(value); ok {
+		fields["time"] = normalized
+	} else {
+		return nil, newInputError(map[string][]string{"time": {"Datetime has wrong format."}})
+	}
+	delete(fields, "id")
+
+	foreignID, _ := fields["foreign_id"].(string)
+	if foreignID != "" {
+		if existing, ok := s.foreignIDs[foreignID+"|"+fields["time"].(string)]; ok {
+			return existing, nil
+		}
+	}
+
+	s.seq++
+	a := &activity{
+		id:     newID(),
+		seq:    s.seq,
+		fields: fields,
+	}
+	s.activities[a.id] = a
+	if foreignID != "" {
+		s.foreignIDs[foreignID+"|"+a.field("time")] = a
+	}
+	return a, nil
+}
+
+// add adds an activity to a feed, and copies it to the feeds following it
+func (s *store) add(feed feedID, a *activity) {
+	s.insert(feed, &entry{activity: a})
+	for _, f := range s.followers(feed) {
+		s.insert(f.source, &entry{activity: a, origin: feed})
+	}
+}
+
+// insert adds an entry to a feed, keeping the feed ordered newest first
+func (s *store) insert(feed feedID, e *entry) {
+	entries := s.feeds[feed]
+	for _, existing := range entries {
+		if existing.activity == e.activity {
+			return
+		}
+	}
+
+	entries = append(entries, e)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].activity.newerThan(entries[j].activity)
+	})
+	s.feeds[feed] = entries
+
+	// a new activity makes its notification group unread and unseen again
+	delete(s.states[feed], e.activity.groupKey())
+}
+
+// remove removes the activities matching a condition from a feed, and the copies of them in the feeds following it
+func (s *store) remove(feed feedID, match func(a *activity) bool) {
+	s.filter(feed, func(e *entry) bool {
+		return match(e.activity)
+	})
+	for _, f := range s.followers(feed) {
+		s.filter(f.source, func(e *entry) bool {
+			return e.origin == feed && match(e.activity)
+		})
+	}
+}
+
+// filter removes the entries matching a condition from a feed
+func (s *store) filter(feed feedID, match func(e *entry) bool) {
+	var kept []*entry
+	for _, e := range s.feeds[feed] {
+		if !match(e) {
+			kept = append(kept, e)
+		}
+	}
+	s.feeds[feed] = kept
+}
+
+// follow makes source follow target and copies up to copyLimit of the latest activities of target
+func (s *store) follow(source feedID, target feedID, copyLimit int) {
+	if s.findFollow(source, target) == nil {
+		s.follows = append(s.follows, &follow{
+			source:    source,
+			target:    target,
+			createdAt: time.Now(),
+		})
+	}
+
+	for i, e := range s.feeds[target] {
+		if i >= copyLimit {
+			break
+		}
+		s.insert(source, &entry{activity: e.activity, origin: target})
+	}
+}
+
+// unfollow makes source stop following target
+// unless keepHistory is set, the activities copied from target are removed from source
+func (s *store) unfollow(source feedID, target feedID, keepHistory bool) {
+	var kept []*follow
+	for _, f := range s.follows {
+		if f.source != source || f.target != target {
+			kept = append(kept, f)
+		}
+	}
+	s.follows = kept
+
+	if !keepHistory {
+		s.filter(source, func(e *entry) bool {
+			return e.origin == target
+		})
+	}
+}
+
+func (s *store) findFollow(source feedID, target feedID) *follow {
+	for _, f := range s.follows {
+		if f.source == source && f.target == target {
+			return f
+		}
+	}
+	return nil
+}
+
+// followers returns the follow relationships targeting a feed, the latest first
+func (s *store) followers(feed feedID) []*follow {
+	var follows []*follow
+	for i := len(s.follows) - 1; i >= 0; i-- {
+		if s.follows[i].target == feed {
+			follows = append(follows, s.follows[i])
+		}
+	}
+	return follows
+}
+
+// following returns the follow relationships of a feed, the latest first
+func (s *store) following(feed feedID) []*follow {
+	var follows []*follow
+	for i := len(s.follows) - 1; i >= 0; i-- {
+		if s.follows[i].source == feed {
+			follows = append(follows, s.follows[i])
+		}
+	}
+	return follows
+}
+
+// group is an aggregation group of an aggregated or notification feed
+type group struct {
+	id      string
+	key     string
+	entries []*entry
+}
+
+// groups aggregates the activities of a feed, the group with the latest activity first
+func (s *store) groups(feed feedID) []*group {
+	var groups []*group
+	byKey := make(map[string]*group)
+	for _, e := range s.feeds[feed] {
+		key := e.activity.groupKey()
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{
+				id:  groupID(feed, key),
+				key: key,
+			}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.entries = append(g.entries, e)
+	}
+	return groups
+}
+
+// state returns the read and seen state of a notification group
+func (s *store) state(feed feedID, g *group) *groupState {
+	states, ok := s.states[feed]
+	if !ok {
+		states = make(map[string]*groupState)
+		s.states[feed] = states
+	}
+	state, ok := states[g.key]
+	if !ok {
+		state = &groupState{}
+		states[g.key] = state
+	}
+	return state
+}
+
+// newID returns a random uuid
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b[:])
+}
+
+// groupID returns a stable uuid for a group of a feed
+func groupID(feed feedID, key string) string {
+	sum := sha1.Sum([]byte(string(feed) + "/" + key))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return formatUUID(sum[:16])
+}
+
+func formatUUID(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
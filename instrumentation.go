@@ -0,0 +1,104 @@
+package getstream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// RequestEvent describes a request made to the API
+type RequestEvent struct {
+	Method string
+	// Endpoint is the path template of the request, like "feed/{slug}/{id}/"
+	Endpoint string
+	// StatusCode is the status of the last response, 0 when no response was received
+	StatusCode int
+
+	Start time.Time
+	// Latency is measured by the client and includes retries and rate limit waits
+	Latency time.Duration
+	// APIDuration is the time the API reported it took to handle the last attempt
+	APIDuration time.Duration
+	Retries     int
+
+	BytesSent     int
+	BytesReceived int
+
+	Err error
+}
+
+// Instrumentation is notified of every request made by a Client
+// It can be used to report spans to a tracer and latency or error metrics to a metrics backend
+type Instrumentation interface {
+	// StartRequest is called before the first attempt of a request
+	// The returned context is used for the request and passed to EndRequest, so it can carry a span
+	StartRequest(ctx context.Context, method string, endpoint string) context.Context
+	// EndRequest is called once the request is done, after all of its retries
+	EndRequest(ctx context.Context, event RequestEvent)
+}
+
+// NopInstrumentation is an Instrumentation which does nothing, it is used when none is configured
+type NopInstrumentation struct{}
+
+// StartRequest returns the context unchanged
+func (NopInstrumentation) StartRequest(ctx context.Context, method string, endpoint string) context.Context {
+	return ctx
+}
+
+// EndRequest does nothing
+func (NopInstrumentation) EndRequest(ctx context.Context, event RequestEvent) {}
+
+// InstrumentationRecorder is an Instrumentation keeping every event in memory
+// It is meant to be used in tests, the zero value is ready to use
+type InstrumentationRecorder struct {
+	mu     sync.Mutex
+	events []RequestEvent
+}
+
+// StartRequest returns the context unchanged
+func (r *InstrumentationRecorder) StartRequest(ctx context.Context, method string, endpoint string) context.Context {
+	return ctx
+}
+
+// EndRequest records the event
+func (r *InstrumentationRecorder) EndRequest(ctx context.Context, event RequestEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+// Events returns a copy of the recorded events, oldest first
+func (r *InstrumentationRecorder) Events() []RequestEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]RequestEvent, len(r.events))
+	copy(events, r.events)
+	return events
+}
+
+// Reset forgets all recorded events
+func (r *InstrumentationRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = nil
+}
+
+// parseAPIDuration reads the "duration" the API reports in its response bodies
+func parseAPIDuration(body []byte) time.Duration {
+	var payload struct {
+		Duration string `json:"duration"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return 0
+	}
+
+	duration, err := time.ParseDuration(payload.Duration)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
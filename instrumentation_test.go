@@ -0,0 +1,67 @@
+package getstream_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+func TestInstrumentationRecorder(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"exception": "Unavailable", "duration": "2ms"}`))
+			return
+		}
+		w.Write([]byte(`{"duration": "12.5ms", "results": []}`))
+	}))
+	defer server.Close()
+
+	recorder := &getstream.InstrumentationRecorder{}
+	policy := getstream.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+
+	client := newServerTestClient(t, server.URL, getstream.WithRetryPolicy(policy), func(cfg *getstream.Config) { cfg.Instrumentation = recorder })
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatal("Expected a single event, got:", len(events))
+	}
+
+	event := events[0]
+	if event.Method != "GET" || event.Endpoint != "feed/{slug}/{id}/" {
+		t.Error("Unexpected method or endpoint:", event.Method, event.Endpoint)
+	}
+	if event.StatusCode != http.StatusOK {
+		t.Error("Expected status 200, got:", event.StatusCode)
+	}
+	if event.Retries != 1 {
+		t.Error("Expected 1 retry, got:", event.Retries)
+	}
+	if event.APIDuration != 12500*time.Microsecond {
+		t.Error("Expected the API duration to be 12.5ms, got:", event.APIDuration)
+	}
+	if event.BytesReceived == 0 || event.Latency <= 0 || event.Err != nil {
+		t.Error("Unexpected event:", event)
+	}
+
+	recorder.Reset()
+	if len(recorder.Events()) != 0 {
+		t.Fatal("Expected Reset to forget all events")
+	}
+}
@@ -0,0 +1,352 @@
+package getstream
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// pageCursor is the position of a page in a feed or follow listing
+type pageCursor struct {
+	idLT   string
+	offset int
+}
+
+// pageIterator walks the pages of a listing one at a time
+// fetch reads the page at the given cursor and returns the number of items in it,
+// along with the cursor of the next page and whether there is one
+type pageIterator struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, cursor pageCursor) (size int, next pageCursor, more bool, err error)
+
+	cursor pageCursor
+	size   int
+	index  int
+	last   bool
+	err    error
+}
+
+func newPageIterator(ctx context.Context, cursor pageCursor, fetch func(ctx context.Context, cursor pageCursor) (int, pageCursor, bool, error)) pageIterator {
+	return pageIterator{
+		ctx:    ctx,
+		fetch:  fetch,
+		cursor: cursor,
+		index:  -1,
+	}
+}
+
+// advance moves to the next item, fetching the next page when the current one is exhausted
+// it returns false once the listing is exhausted or a request failed
+func (it *pageIterator) advance() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+	for it.index >= it.size {
+		if it.last {
+			return false
+		}
+
+		size, next, more, err := it.fetch(it.ctx, it.cursor)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.size = size
+		it.index = 0
+
+		if !more || next == it.cursor {
+			// no further page, or the API handed back the cursor we just used
+			it.last = true
+		}
+		it.cursor = next
+	}
+	return true
+}
+
+// end stops the iteration, no further page is fetched
+func (it *pageIterator) end() {
+	it.last = true
+	it.size = 0
+}
+
+// parseNext reads the cursor from the Next link of a feed response
+// ok is false when there is no next page
+func parseNext(next string) (cursor pageCursor, ok bool) {
+	if next == "" {
+		return cursor, false
+	}
+
+	nextURL, err := url.Parse(next)
+	if err != nil {
+		return cursor, false
+	}
+
+	query := nextURL.Query()
+	cursor.idLT = query.Get("id_lt")
+	cursor.offset, _ = strconv.Atoi(query.Get("offset"))
+	if cursor.idLT == "" && cursor.offset == 0 {
+		return cursor, false
+	}
+	return cursor, true
+}
+
+// FlatFeedIterator walks all the Activities of a FlatFeed, one page at a time
+//
+//	it := feed.Iterate(&getstream.GetFlatFeedInput{Limit: 100})
+//	for it.Next() {
+//		activity := it.Activity()
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type FlatFeedIterator struct {
+	pages   pageIterator
+	page    []*Activity
+	stop    func(*Activity) bool
+	current *Activity
+}
+
+// Iterate returns an iterator over the Activities of a FlatFeed
+// input.Limit is the page size, IDLT and Offset set where to start
+func (f *FlatFeed) Iterate(input *GetFlatFeedInput) *FlatFeedIterator {
+	return f.IterateContext(context.Background(), input)
+}
+
+// IterateContext is the context.Context aware version of Iterate
+func (f *FlatFeed) IterateContext(ctx context.Context, input *GetFlatFeedInput) *FlatFeedIterator {
+	var params GetFlatFeedInput
+	if input != nil {
+		params = *input
+	}
+
+	it := &FlatFeedIterator{}
+	it.pages = newPageIterator(ctx, pageCursor{idLT: params.IDLT, offset: params.Offset}, func(ctx context.Context, cursor pageCursor) (int, pageCursor, bool, error) {
+		params.IDLT = cursor.idLT
+		params.Offset = cursor.offset
+
+		output, err := f.ActivitiesContext(ctx, &params)
+		if err != nil {
+			return 0, cursor, false, err
+		}
+		it.page = output.Activities
+		next, more := parseNext(output.Next)
+		return len(output.Activities), next, more, nil
+	})
+	return it
+}
+
+// StopWhen sets a condition to end the iteration early
+// the Activity matching the condition is not returned
+func (it *FlatFeedIterator) StopWhen(stop func(activity *Activity) bool) *FlatFeedIterator {
+	it.stop = stop
+	return it
+}
+
+// Next advances to the next Activity, it returns false when the iteration is over
+func (it *FlatFeedIterator) Next() bool {
+	it.current = nil
+	if !it.pages.advance() {
+		return false
+	}
+
+	activity := it.page[it.pages.index]
+	if it.stop != nil && it.stop(activity) {
+		it.pages.end()
+		return false
+	}
+	it.current = activity
+	return true
+}
+
+// Activity returns the current Activity
+func (it *FlatFeedIterator) Activity() *Activity {
+	return it.current
+}
+
+// Err returns the error which ended the iteration, if any
+func (it *FlatFeedIterator) Err() error {
+	return it.pages.err
+}
+
+// AggregatedFeedIterator walks all the Groups of an AggregatedFeed, one page at a time
+type AggregatedFeedIterator struct {
+	pages   pageIterator
+	page    []*AggregatedFeedGroup
+	stop    func(*AggregatedFeedGroup) bool
+	current *AggregatedFeedGroup
+}
+
+// Iterate returns an iterator over the Groups of an AggregatedFeed
+// input.Limit is the page size, IDLT and Offset set where to start
+func (f *AggregatedFeed) Iterate(input *GetAggregatedFeedInput) *AggregatedFeedIterator {
+	return f.IterateContext(context.Background(), input)
+}
+
+// IterateContext is the context.Context aware version of Iterate
+func (f *AggregatedFeed) IterateContext(ctx context.Context, input *GetAggregatedFeedInput) *AggregatedFeedIterator {
+	var params GetAggregatedFeedInput
+	if input != nil {
+		params = *input
+	}
+
+	it := &AggregatedFeedIterator{}
+	it.pages = newPageIterator(ctx, pageCursor{idLT: params.IDLT, offset: params.Offset}, func(ctx context.Context, cursor pageCursor) (int, pageCursor, bool, error) {
+		params.IDLT = cursor.idLT
+		params.Offset = cursor.offset
+
+		output, err := f.ActivitiesContext(ctx, &params)
+		if err != nil {
+			return 0, cursor, false, err
+		}
+		it.page = output.Results
+		next, more := parseNext(output.Next)
+		return len(output.Results), next, more, nil
+	})
+	return it
+}
+
+// StopWhen sets a condition to end the iteration early
+// the Group matching the condition is not returned
+func (it *AggregatedFeedIterator) StopWhen(stop func(group *AggregatedFeedGroup) bool) *AggregatedFeedIterator {
+	it.stop = stop
+	return it
+}
+
+// Next advances to the next Group, it returns false when the iteration is over
+func (it *AggregatedFeedIterator) Next() bool {
+	it.current = nil
+	if !it.pages.advance() {
+		return false
+	}
+
+	group := it.page[it.pages.index]
+	if it.stop != nil && it.stop(group) {
+		it.pages.end()
+		return false
+	}
+	it.current = group
+	return true
+}
+
+// Group returns the current Group
+func (it *AggregatedFeedIterator) Group() *AggregatedFeedGroup {
+	return it.current
+}
+
+// Err returns the error which ended the iteration, if any
+func (it *AggregatedFeedIterator) Err() error {
+	return it.pages.err
+}
+
+// NotificationFeedIterator walks all the Groups of a NotificationFeed, one page at a time
+type NotificationFeedIterator struct {
+	pages   pageIterator
+	page    []*NotificationFeedGroup
+	stop    func(*NotificationFeedGroup) bool
+	current *NotificationFeedGroup
+}
+
+// Iterate returns an iterator over the Groups of a NotificationFeed
+// input.Limit is the page size, IDLT and Offset set where to start
+func (f *NotificationFeed) Iterate(input *GetNotificationFeedInput) *NotificationFeedIterator {
+	return f.IterateContext(context.Background(), input)
+}
+
+// IterateContext is the context.Context aware version of Iterate
+func (f *NotificationFeed) IterateContext(ctx context.Context, input *GetNotificationFeedInput) *NotificationFeedIterator {
+	var params GetNotificationFeedInput
+	if input != nil {
+		params = *input
+	}
+
+	it := &NotificationFeedIterator{}
+	it.pages = newPageIterator(ctx, pageCursor{idLT: params.IDLT, offset: params.Offset}, func(ctx context.Context, cursor pageCursor) (int, pageCursor, bool, error) {
+		params.IDLT = cursor.idLT
+		params.Offset = cursor.offset
+
+		output, err := f.ActivitiesContext(ctx, &params)
+		if err != nil {
+			return 0, cursor, false, err
+		}
+		it.page = output.Results
+		next, more := parseNext(output.Next)
+		return len(output.Results), next, more, nil
+	})
+	return it
+}
+
+// StopWhen sets a condition to end the iteration early
+// the Group matching the condition is not returned
+func (it *NotificationFeedIterator) StopWhen(stop func(group *NotificationFeedGroup) bool) *NotificationFeedIterator {
+	it.stop = stop
+	return it
+}
+
+// Next advances to the next Group, it returns false when the iteration is over
+func (it *NotificationFeedIterator) Next() bool {
+	it.current = nil
+	if !it.pages.advance() {
+		return false
+	}
+
+	group := it.page[it.pages.index]
+	if it.stop != nil && it.stop(group) {
+		it.pages.end()
+		return false
+	}
+	it.current = group
+	return true
+}
+
+// Group returns the current Group
+func (it *NotificationFeedIterator) Group() *NotificationFeedGroup {
+	return it.current
+}
+
+// Err returns the error which ended the iteration, if any
+func (it *NotificationFeedIterator) Err() error {
+	return it.pages.err
+}
+
+// FollowIterator walks the followers or the following of a feed, one page at a time
+type FollowIterator struct {
+	pages   pageIterator
+	page    []*GeneralFeed
+	stop    func(*GeneralFeed) bool
+	current *GeneralFeed
+}
+
+// StopWhen sets a condition to end the iteration early
+// the Feed matching the condition is not returned
+func (it *FollowIterator) StopWhen(stop func(feed *GeneralFeed) bool) *FollowIterator {
+	it.stop = stop
+	return it
+}
+
+// Next advances to the next Feed, it returns false when the iteration is over
+func (it *FollowIterator) Next() bool {
+	it.current = nil
+	if !it.pages.advance() {
+		return false
+	}
+
+	feed := it.page[it.pages.index]
+	if it.stop != nil && it.stop(feed) {
+		it.pages.end()
+		return false
+	}
+	it.current = feed
+	return true
+}
+
+// Feed returns the current Feed
+func (it *FollowIterator) Feed() *GeneralFeed {
+	return it.current
+}
+
+// Err returns the error which ended the iteration, if any
+func (it *FollowIterator) Err() error {
+	return it.pages.err
+}
@@ -0,0 +1,274 @@
+package getstream_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+// newPagingTestClient returns a client pointing to a server paging through activities "9" to "1" with id_lt
+// the server fails with a 500 once a request is made with id_lt set to failAt
+func newPagingTestClient(t *testing.T, results func(ids []string) interface{}, failAt string) (*getstream.Client, *int, func()) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		idLT := r.URL.Query().Get("id_lt")
+		if failAt != "" && idLT == failAt {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"exception": "InternalError", "status_code": 500}`))
+			return
+		}
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit == 0 {
+			limit = 25
+		}
+
+		var ids []string
+		for i := 9; i > 0 && len(ids) < limit; i-- {
+			id := strconv.Itoa(i)
+			if idLT == "" || id < idLT {
+				ids = append(ids, id)
+			}
+		}
+
+		next := ""
+		if len(ids) > 0 && ids[len(ids)-1] != "1" {
+			next = "/api/v1.0" + r.URL.Path + "?id_lt=" + ids[len(ids)-1] + "&limit=" + strconv.Itoa(limit)
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"duration": "1ms",
+			"next":     next,
+			"results":  results(ids),
+		})
+	}))
+
+	client := newServerTestClient(t, server.URL)
+
+	return client, &requests, server.Close
+}
+
+func activityResults(ids []string) interface{} {
+	var results []map[string]string
+	for _, id := range ids {
+		results = append(results, map[string]string{"id": id, "verb": "post"})
+	}
+	return results
+}
+
+func groupResults(ids []string) interface{} {
+	var results []map[string]interface{}
+	for _, id := range ids {
+		results = append(results, map[string]interface{}{
+			"id":         id,
+			"activities": []map[string]string{{"id": id}},
+		})
+	}
+	return results
+}
+
+func TestFlatFeedIterate(t *testing.T) {
+	client, requests, done := newPagingTestClient(t, activityResults, "")
+	defer done()
+
+	feed, _ := client.FlatFeed("flat", "bob")
+
+	var ids string
+	it := feed.Iterate(&getstream.GetFlatFeedInput{Limit: 4})
+	for it.Next() {
+		ids += it.Activity().ID
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids != "987654321" {
+		t.Error("unexpected activities:", ids)
+	}
+	if *requests != 3 {
+		t.Error("expected 3 pages, got", *requests)
+	}
+	if it.Next() {
+		t.Error("expected the iterator to stay exhausted")
+	}
+}
+
+func TestFlatFeedIterateStopWhen(t *testing.T) {
+	client, requests, done := newPagingTestClient(t, activityResults, "")
+	defer done()
+
+	feed, _ := client.FlatFeed("flat", "bob")
+
+	var ids string
+	it := feed.Iterate(&getstream.GetFlatFeedInput{Limit: 2}).StopWhen(func(activity *getstream.Activity) bool {
+		return activity.ID == "6"
+	})
+	for it.Next() {
+		ids += it.Activity().ID
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids != "987" {
+		t.Error("unexpected activities:", ids)
+	}
+	if *requests != 2 {
+		t.Error("expected no page to be fetched after the stop condition, got", *requests)
+	}
+}
+
+func TestFlatFeedIterateError(t *testing.T) {
+	client, _, done := newPagingTestClient(t, activityResults, "6")
+	defer done()
+
+	feed, _ := client.FlatFeed("flat", "bob")
+
+	var ids string
+	it := feed.Iterate(&getstream.GetFlatFeedInput{Limit: 4})
+	for it.Next() {
+		ids += it.Activity().ID
+	}
+
+	if ids != "9876" {
+		t.Error("expected the first page to be returned before the error, got:", ids)
+	}
+	if !getstream.IsServerError(it.Err()) {
+		t.Error("expected a server error, got:", it.Err())
+	}
+	if it.Activity() != nil {
+		t.Error("expected no current activity after the error")
+	}
+}
+
+func TestAggregatedFeedIterate(t *testing.T) {
+	client, requests, done := newPagingTestClient(t, groupResults, "")
+	defer done()
+
+	feed, _ := client.AggregatedFeed("aggregated", "bob")
+
+	var ids string
+	it := feed.Iterate(&getstream.GetAggregatedFeedInput{Limit: 5, IDLT: "8"})
+	for it.Next() {
+		ids += it.Group().ID + it.Group().Activities[0].ID
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids != "77665544332211" {
+		t.Error("unexpected groups:", ids)
+	}
+	if *requests != 2 {
+		t.Error("expected 2 pages, got", *requests)
+	}
+}
+
+func TestNotificationFeedIterate(t *testing.T) {
+	client, _, done := newPagingTestClient(t, groupResults, "")
+	defer done()
+
+	feed, _ := client.NotificationFeed("notification", "bob")
+
+	var ids string
+	it := feed.Iterate(nil).StopWhen(func(group *getstream.NotificationFeedGroup) bool {
+		return group.ID == "3"
+	})
+	for it.Next() {
+		ids += it.Group().ID
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids != "987654" {
+		t.Error("unexpected groups:", ids)
+	}
+}
+
+// newFollowPagingTestClient returns a client pointing to a server paging through 7 followers with limit and offset
+func newFollowPagingTestClient(t *testing.T) (*getstream.Client, *[]string, func()) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("limit")+"/"+r.URL.Query().Get("offset"))
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		results := []map[string]string{}
+		for i := offset; i < 7 && len(results) < limit; i++ {
+			feedID := "timeline:" + strconv.Itoa(i)
+			if i == 3 {
+				feedID = "malformed"
+			}
+			results = append(results, map[string]string{"feed_id": feedID, "target_id": "user:" + strconv.Itoa(i)})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"duration": "1ms",
+			"results":  results,
+		})
+	}))
+
+	client := newServerTestClient(t, server.URL)
+
+	return client, &queries, server.Close
+}
+
+func TestFollowIterator(t *testing.T) {
+	client, queries, done := newFollowPagingTestClient(t)
+	defer done()
+
+	feed, _ := client.FlatFeed("user", "bob")
+
+	var ids string
+	it := feed.IterateFollowers(3)
+	for it.Next() {
+		ids += it.Feed().UserID
+		if it.Feed().Client != client {
+			t.Error("expected the feed to be bound to the client")
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids != "012456" {
+		t.Error("expected every follower but the malformed one, got:", ids)
+	}
+	if strings.Join(*queries, ",") != "3/0,3/3,3/6" {
+		t.Error("unexpected pages:", *queries)
+	}
+}
+
+func TestFollowIteratorFollowingStopWhen(t *testing.T) {
+	client, queries, done := newFollowPagingTestClient(t)
+	defer done()
+
+	feed, _ := client.NotificationFeed("notification", "bob")
+
+	var ids string
+	it := feed.IterateFollowing(0).StopWhen(func(feed *getstream.GeneralFeed) bool {
+		return feed.UserID == "2"
+	})
+	for it.Next() {
+		ids += it.Feed().FeedSlug + it.Feed().UserID
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids != "user0user1" {
+		t.Error("unexpected following:", ids)
+	}
+	if strings.Join(*queries, ",") != "25/0" {
+		t.Error("expected a single page with the default page size, got:", *queries)
+	}
+}
@@ -0,0 +1,22 @@
+package getstream
+
+import "time"
+
+// Logger receives the log lines of a Client, a *log.Logger satisfies it
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// logRequest writes a line describing a finished request to the Logger of the client, if any
+func (c *Client) logRequest(event RequestEvent) {
+	if c.Logger == nil {
+		return
+	}
+
+	latency := event.Latency.Round(time.Millisecond)
+	if event.Err != nil {
+		c.Logger.Printf("getstream: %s %s failed after %s (%d retries): %v", event.Method, event.Endpoint, latency, event.Retries, event.Err)
+		return
+	}
+	c.Logger.Printf("getstream: %s %s %d in %s (%d retries)", event.Method, event.Endpoint, event.StatusCode, latency, event.Retries)
+}
@@ -0,0 +1,27 @@
+package getstream
+
+import "net/http"
+
+// RoundTripFunc performs a single http request against the API
+type RoundTripFunc func(*http.Request) (*http.Response, error)
+
+// Middleware wraps a RoundTripFunc to intercept requests and responses
+// Requests reaching a Middleware are fully built: the URL, base headers and signature are set.
+// A Middleware reading the response body must replace it, since the client reads it afterwards.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Use registers middlewares on the client
+// The first registered Middleware is the outermost one and sees every request first.
+// Middlewares run once per attempt when requests are retried.
+func (c *Client) Use(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+}
+
+// roundTrip builds the middleware chain around the http client
+func (c *Client) roundTrip() RoundTripFunc {
+	next := RoundTripFunc(c.HTTP.Do)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		next = c.middlewares[i](next)
+	}
+	return next
+}
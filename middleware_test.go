@@ -0,0 +1,98 @@
+package getstream_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Seen", r.Header.Get("X-Trace"))
+		w.Write([]byte(`{"duration": "1ms", "results": []}`))
+	}))
+	defer server.Close()
+
+	client := newServerTestClient(t, server.URL)
+
+	var calls []string
+	var captured []byte
+	client.Use(
+		func(next getstream.RoundTripFunc) getstream.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, "outer")
+				if req.Header.Get("Authorization") == "" {
+					t.Error("Expected the request to be signed before reaching the middlewares")
+				}
+				req.Header.Set("X-Trace", "abc")
+				return next(req)
+			}
+		},
+		func(next getstream.RoundTripFunc) getstream.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, "inner")
+				if req.Header.Get("X-Trace") != "abc" {
+					t.Error("Expected the outer middleware to run first")
+				}
+				resp, err := next(req)
+				if err != nil {
+					return nil, err
+				}
+				captured, _ = ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				resp.Body = ioutil.NopCloser(bytes.NewReader(captured))
+				return resp, nil
+			}
+		},
+	)
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 2 || calls[0] != "outer" || calls[1] != "inner" {
+		t.Fatal("Unexpected middleware calls:", calls)
+	}
+	if string(captured) != `{"duration": "1ms", "results": []}` {
+		t.Fatal("Expected the middleware to capture the response, got:", string(captured))
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	client, err := getstream.New(&getstream.Config{
+		APIKey:    "my_key",
+		APISecret: "my_secret",
+		AppID:     "111111",
+		Location:  "us-east"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chaos := errors.New("chaos")
+	client.Use(func(next getstream.RoundTripFunc) getstream.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			return nil, chaos
+		}
+	})
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if !errors.Is(err, chaos) {
+		t.Fatal("Expected the middleware error, got:", err)
+	}
+}
@@ -0,0 +1,102 @@
+package getstream
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Option configures a Client built by NewClient
+type Option func(*Config)
+
+// NewClient returns a GetStream client for the given API credentials, configured by the options
+//
+//	client, err := getstream.NewClient(apiKey, apiSecret,
+//		getstream.WithLocation("us-east"),
+//		getstream.WithTimeout(500*time.Millisecond),
+//	)
+//
+// Pass an empty apiSecret along with WithToken to build a client which only holds a token.
+// The Config of the returned client is a copy owned by the client.
+func NewClient(apiKey string, apiSecret string, opts ...Option) (*Client, error) {
+	cfg := &Config{
+		APIKey:    apiKey,
+		APISecret: apiSecret,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return New(cfg)
+}
+
+// WithAppID sets the app id of the client
+func WithAppID(appID string) Option {
+	return func(cfg *Config) {
+		cfg.AppID = appID
+	}
+}
+
+// WithLocation sets the region of the API, like "us-east"
+func WithLocation(location string) Option {
+	return func(cfg *Config) {
+		cfg.Location = location
+	}
+}
+
+// WithVersion sets the version of the API, "v1.0" by default
+func WithVersion(version string) Option {
+	return func(cfg *Config) {
+		cfg.Version = version
+	}
+}
+
+// WithBaseURL sets the url of the API, instead of the one derived from the location and version
+func WithBaseURL(baseURL *url.URL) Option {
+	return func(cfg *Config) {
+		cfg.BaseURL = baseURL
+	}
+}
+
+// WithTimeout sets the time limit of a request, a negative timeout leaves deadlines to the context
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.SetTimeoutDuration(timeout)
+	}
+}
+
+// WithHTTPClient makes the client send its requests with httpClient
+// The timeouts and connection settings of the Config are ignored
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(cfg *Config) {
+		cfg.HTTPClient = httpClient
+	}
+}
+
+// WithLogger makes the client write a line for every request to logger
+func WithLogger(logger Logger) Option {
+	return func(cfg *Config) {
+		cfg.Logger = logger
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests, nil disables retries
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(cfg *Config) {
+		cfg.Retry = policy
+	}
+}
+
+// WithSecondarySecrets sets the previous API secrets of the application, see Signer.SecondarySecrets
+func WithSecondarySecrets(secrets ...string) Option {
+	return func(cfg *Config) {
+		cfg.SecondarySecrets = secrets
+	}
+}
+
+// WithToken builds a client which signs its requests with a token instead of the API secret
+// Such a client can only do what the token allows
+func WithToken(token string) Option {
+	return func(cfg *Config) {
+		cfg.Token = token
+	}
+}
@@ -0,0 +1,184 @@
+package getstream_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	getstream "github.com/GetStream/stream-go"
+	"github.com/GetStream/stream-go/getstreamtest"
+)
+
+func TestNewClient(t *testing.T) {
+	retry := getstream.DefaultRetryPolicy()
+	client, err := getstream.NewClient("my_key", "my_secret",
+		getstream.WithAppID("111111"),
+		getstream.WithLocation("us-east"),
+		getstream.WithVersion("v2.0"),
+		getstream.WithTimeout(250*time.Millisecond),
+		getstream.WithRetryPolicy(retry),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.Config.APIKey != "my_key" || client.Config.APISecret != "my_secret" || client.Config.AppID != "111111" {
+		t.Error("unexpected config:", client.Config)
+	}
+	if client.BaseURL.String() != "https://us-east-api.getstream.io/api/v2.0/" {
+		t.Error("unexpected BaseURL:", client.BaseURL.String())
+	}
+	if client.HTTP.Timeout != 250*time.Millisecond {
+		t.Error("expected a 250ms timeout, got", client.HTTP.Timeout)
+	}
+	if client.Retry == nil || client.Retry.MaxAttempts != retry.MaxAttempts {
+		t.Error("expected the retry policy to be set, got:", client.Retry)
+	}
+}
+
+func TestNewClientMissingCredentials(t *testing.T) {
+	_, err := getstream.NewClient("", "my_secret")
+	if err == nil {
+		t.Error("expected a missing API key to be rejected")
+	}
+	_, err = getstream.NewClient("my_key", "")
+	if err == nil {
+		t.Error("expected a missing API secret and token to be rejected")
+	}
+}
+
+func TestNewClientWithToken(t *testing.T) {
+	client, err := getstream.NewClient("my_key", "", getstream.WithToken("my_token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Config.Token != "my_token" || client.Signer.Secret != "my_token" {
+		t.Error("expected the client to sign with the token, got:", client.Config.Token, client.Signer.Secret)
+	}
+}
+
+func TestNewClientWithSecondarySecrets(t *testing.T) {
+	client, err := getstream.NewClient("my_key", "new_secret", getstream.WithSecondarySecrets("old_secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken, _ := getstream.Signer{Secret: "old_secret"}.GenerateFeedScopeToken(getstream.ScopeContextFeed, getstream.ScopeActionRead, "")
+	claims, err := client.Signer.VerifyToken(oldToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.SecretIndex != 1 {
+		t.Error("expected the secondary secret to verify the token, got", claims.SecretIndex)
+	}
+}
+
+func TestNewClientWithHTTPClient(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Minute}
+	client, err := getstream.NewClient("my_key", "my_secret",
+		getstream.WithHTTPClient(httpClient),
+		getstream.WithTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.HTTP != httpClient || client.HTTP.Timeout != time.Minute {
+		t.Error("expected the http client to be used as is")
+	}
+}
+
+func TestNewDoesNotModifyConfig(t *testing.T) {
+	cfg := &getstream.Config{
+		APIKey:    "my_key",
+		APISecret: "my_secret",
+		Token:     "my_token",
+	}
+
+	first, err := getstream.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APISecret != "my_secret" || cfg.Token != "my_token" || cfg.Version != "" || cfg.TimeoutDuration != 0 {
+		t.Error("expected the config to be left untouched, got:", cfg)
+	}
+	if first.Config == cfg {
+		t.Fatal("expected the client to hold a copy of the config")
+	}
+
+	first.Config.APIKey = "changed"
+	second, err := getstream.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Config.APIKey != "my_key" {
+		t.Error("expected changes to the config of a client not to leak, got:", second.Config.APIKey)
+	}
+}
+
+func TestNewCopiesConfigValues(t *testing.T) {
+	retry := getstream.DefaultRetryPolicy()
+	baseURL, _ := url.Parse("https://proxy.example.com/api/v1.0/")
+	cfg := &getstream.Config{
+		APIKey:           "my_key",
+		APISecret:        "my_secret",
+		SecondarySecrets: []string{"old_secret"},
+		BaseURL:          baseURL,
+		Retry:            retry,
+	}
+
+	client, err := getstream.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retry.MaxAttempts = 10
+	retry.RetryStatusCodes[0] = 418
+	cfg.SecondarySecrets[0] = "changed"
+	baseURL.Host = "changed.example.com"
+
+	if client.Retry.MaxAttempts != 3 || client.Config.Retry.MaxAttempts != 3 || client.Retry.RetryStatusCodes[0] != 429 {
+		t.Error("expected the retry policy to be copied, got:", client.Retry)
+	}
+	if client.Signer.SecondarySecrets[0] != "old_secret" || client.Config.SecondarySecrets[0] != "old_secret" {
+		t.Error("expected the secondary secrets to be copied, got:", client.Signer.SecondarySecrets)
+	}
+	if client.BaseURL.Host != "proxy.example.com" || client.Config.BaseURL.Host != "proxy.example.com" {
+		t.Error("expected the BaseURL to be copied, got:", client.Config.BaseURL)
+	}
+}
+
+func TestNewClientWithLogger(t *testing.T) {
+	server := getstreamtest.NewServer("key", "secret")
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client, err := server.NewClient(getstream.WithLogger(log.New(&buf, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, _ := client.FlatFeed("user", "bob")
+	_, err = feed.Activities(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = feed.AddActivity(&getstream.Activity{Actor: "user:bob", Verb: "post"})
+	if err == nil {
+		t.Fatal("expected an invalid activity to be rejected")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("expected a line per request, got:", lines)
+	}
+	if !strings.HasPrefix(lines[0], "getstream: GET feed/{slug}/{id}/ 200 in ") {
+		t.Error("unexpected log line:", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "getstream: POST feed/{slug}/{id}/ failed after ") {
+		t.Error("unexpected log line:", lines[1])
+	}
+}
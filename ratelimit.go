@@ -0,0 +1,151 @@
+package getstream
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit is the rate limit state reported by the API for an endpoint
+// through the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// Exhausted reports if no requests are left before the limit resets
+func (r RateLimit) Exhausted() bool {
+	return r.Remaining <= 0 && time.Now().Before(r.Reset)
+}
+
+// parseRateLimit reads the rate limit headers of a response
+// ok is false when the response carries no rate limit information
+func parseRateLimit(header http.Header) (rateLimit RateLimit, ok bool) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return rateLimit, false
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return rateLimit, false
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return rateLimit, false
+	}
+
+	return RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}, true
+}
+
+// RateLimitError is returned when the API responds with 429 Too Many Requests
+type RateLimitError struct {
+	Err       *Error
+	RateLimit RateLimit
+}
+
+var _ error = &RateLimitError{}
+
+func (e *RateLimitError) Error() string {
+	str := "rate limit exceeded"
+	if !e.RateLimit.Reset.IsZero() {
+		str += ", resets at " + e.RateLimit.Reset.UTC().Format(time.RFC3339)
+	}
+	if e.Err != nil {
+		if msg := e.Err.Error(); msg != "" {
+			str += ": " + msg
+		}
+	}
+	return str
+}
+
+// Unwrap returns the API error, so errors.As(err, **Error) works for rate limited requests too
+func (e *RateLimitError) Unwrap() error {
+	if e.Err == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// Is matches ErrRateLimited
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RateLimit returns the last rate limit state observed for an endpoint
+// Endpoints are named after the HTTP method and the request path with feed slugs and ids replaced,
+// like "POST feed/{slug}/{id}/", since the API limits each method of a path separately
+func (c *Client) RateLimit(endpoint string) (RateLimit, bool) {
+	c.rateLimitsMu.Lock()
+	defer c.rateLimitsMu.Unlock()
+
+	rateLimit, ok := c.rateLimits[endpoint]
+	return rateLimit, ok
+}
+
+// RateLimits returns a copy of the last rate limit state observed for every endpoint
+func (c *Client) RateLimits() map[string]RateLimit {
+	c.rateLimitsMu.Lock()
+	defer c.rateLimitsMu.Unlock()
+
+	rateLimits := make(map[string]RateLimit, len(c.rateLimits))
+	for endpoint, rateLimit := range c.rateLimits {
+		rateLimits[endpoint] = rateLimit
+	}
+	return rateLimits
+}
+
+func (c *Client) setRateLimit(endpoint string, rateLimit RateLimit) {
+	c.rateLimitsMu.Lock()
+	defer c.rateLimitsMu.Unlock()
+
+	if c.rateLimits == nil {
+		c.rateLimits = make(map[string]RateLimit)
+	}
+	c.rateLimits[endpoint] = rateLimit
+}
+
+// rateLimitKey names the endpoint a rate limit is tracked for, like "GET feed/{slug}/{id}/"
+func rateLimitKey(method string, endpoint string) string {
+	return method + " " + endpoint
+}
+
+// waitForRateLimit blocks until the rate limit of an endpoint resets, when it is known to be exhausted
+func (c *Client) waitForRateLimit(ctx context.Context, endpoint string) error {
+	rateLimit, ok := c.RateLimit(endpoint)
+	if !ok || !rateLimit.Exhausted() {
+		return nil
+	}
+	return sleepContext(ctx, rateLimit.Reset.Sub(time.Now()))
+}
+
+// endpointName turns a request path into the name of its endpoint
+// feed slugs, user ids, activity ids and follow targets are replaced by placeholders
+func endpointName(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "feed" {
+		return path
+	}
+
+	name := "feed/{slug}/{id}/"
+	switch {
+	case len(parts) == 3:
+	case parts[3] == "following" && len(parts) > 4:
+		name += "following/{target}/"
+	case parts[3] == "following" || parts[3] == "followers":
+		name += parts[3] + "/"
+	default:
+		name += "{activity_id}/"
+	}
+	return name
+}
@@ -0,0 +1,152 @@
+package getstream_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+func newRateLimitServer(status int, remaining int, reset time.Time) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(status)
+		if status == http.StatusTooManyRequests {
+			w.Write([]byte(`{"code": 9, "exception": "RateLimitReached", "status_code": 429}`))
+			return
+		}
+		w.Write([]byte(`{"duration": "1ms", "results": []}`))
+	}))
+}
+
+func TestRateLimitObserved(t *testing.T) {
+	reset := time.Now().Add(time.Minute)
+	server := newRateLimitServer(http.StatusOK, 42, reset)
+	defer server.Close()
+
+	client := newServerTestClient(t, server.URL)
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := client.RateLimit("GET feed/{slug}/{id}/"); ok {
+		t.Fatal("Expected no rate limit before the first request")
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rateLimit, ok := client.RateLimit("GET feed/{slug}/{id}/")
+	if !ok {
+		t.Fatal("Expected the rate limit to be recorded, got:", client.RateLimits())
+	}
+	if rateLimit.Limit != 100 || rateLimit.Remaining != 42 || rateLimit.Reset.Unix() != reset.Unix() {
+		t.Fatal("Unexpected rate limit:", rateLimit)
+	}
+	if rateLimit.Exhausted() {
+		t.Fatal("Expected the rate limit not to be exhausted")
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	reset := time.Now().Add(time.Minute)
+	server := newRateLimitServer(http.StatusTooManyRequests, 0, reset)
+	defer server.Close()
+
+	client := newServerTestClient(t, server.URL)
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	rateLimitErr, ok := err.(*getstream.RateLimitError)
+	if !ok {
+		t.Fatal("Expected a RateLimitError, got:", err)
+	}
+	if rateLimitErr.RateLimit.Reset.Unix() != reset.Unix() {
+		t.Fatal("Expected the reset time to be set, got:", rateLimitErr.RateLimit.Reset)
+	}
+	if rateLimitErr.Err.Exception != "RateLimitReached" {
+		t.Fatal("Expected the API error to be kept, got:", rateLimitErr.Err)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	server := newRateLimitServer(http.StatusOK, 0, time.Now().Add(time.Minute))
+	defer server.Close()
+
+	client := newServerTestClient(t, server.URL, func(cfg *getstream.Config) { cfg.WaitOnRateLimit = true })
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first request has no rate limit information yet and goes through
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second one waits for the reset, which takes longer than the context allows
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = feed.ActivitiesContext(ctx, &getstream.GetFlatFeedInput{})
+	if err != context.DeadlineExceeded {
+		t.Fatal("Expected the client to wait for the rate limit reset, got:", err)
+	}
+}
+
+func TestRateLimitPerMethod(t *testing.T) {
+	server := newRateLimitServer(http.StatusOK, 0, time.Now().Add(time.Minute))
+	defer server.Close()
+
+	client := newServerTestClient(t, server.URL, func(cfg *getstream.Config) { cfg.WaitOnRateLimit = true })
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.AddActivity(&getstream.Activity{Actor: "bob", Verb: "like", Object: "cake"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rateLimit, ok := client.RateLimit("POST feed/{slug}/{id}/"); !ok || !rateLimit.Exhausted() {
+		t.Fatal("Expected the POST rate limit to be exhausted, got:", client.RateLimits())
+	}
+
+	// reading the feed is limited separately and must not wait for the POST limit to reset
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = feed.ActivitiesContext(ctx, &getstream.GetFlatFeedInput{})
+	if err != nil {
+		t.Fatal("Expected the GET request not to be blocked, got:", err)
+	}
+}
+
+func TestRateLimitErrorClassification(t *testing.T) {
+	var err error = &getstream.RateLimitError{
+		Err: &getstream.Error{StatusCode: http.StatusTooManyRequests, Exception: "RateLimitReached"},
+	}
+
+	if !getstream.IsRateLimited(err) {
+		t.Fatal("Expected a RateLimitError to be rate limited")
+	}
+
+	var getStreamError *getstream.Error
+	if !errors.As(err, &getStreamError) || getStreamError.Exception != "RateLimitReached" {
+		t.Fatal("Expected errors.As to find the API error")
+	}
+}
@@ -0,0 +1,239 @@
+package getstream
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried by the Client
+// A nil RetryPolicy (the default) means every request is attempted exactly once
+//
+// GET and DELETE requests are idempotent and are always eligible for a retry.
+// POST requests are only retried when RetryWrites is set and they are safe to repeat: activities carrying a ForeignID,
+// which lets the API de-duplicate the activity if an earlier attempt did reach it, and partial updates,
+// which set and unset the same fields again.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it doubles on every following retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts, including the waits asked for by the API
+	MaxDelay time.Duration
+	// Jitter is the fraction (0 to 1) of every delay which is randomized
+	Jitter float64
+
+	// RetryStatusCodes are the HTTP status codes which will be retried
+	RetryStatusCodes []int
+	// RetryTransportErrors retries requests which failed without a response, like connection resets and timeouts
+	RetryTransportErrors bool
+	// RespectRetryAfter waits for the delay sent in a Retry-After header instead of the computed backoff
+	// rate limited responses without the header wait until X-RateLimit-Reset, both are capped at MaxDelay
+	RespectRetryAfter bool
+	// RetryWrites opts POST requests adding activities with a ForeignID and partial updates into being retried
+	RetryWrites bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy suitable for most applications
+// It retries up to 3 times on 429 and 5xx responses as well as on transport errors
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:          3,
+		BaseDelay:            100 * time.Millisecond,
+		MaxDelay:             2 * time.Second,
+		Jitter:               0.5,
+		RetryStatusCodes:     []int{429, 500, 502, 503, 504},
+		RetryTransportErrors: true,
+		RespectRetryAfter:    true,
+	}
+}
+
+// clone returns a copy of the policy, nil for a nil policy
+func (p *RetryPolicy) clone() *RetryPolicy {
+	if p == nil {
+		return nil
+	}
+	policy := *p
+	policy.RetryStatusCodes = append([]int(nil), p.RetryStatusCodes...)
+	return &policy
+}
+
+// attempts returns the number of attempts allowed for a request
+func (p *RetryPolicy) attempts(ctx context.Context, method string) int {
+	if p == nil || p.MaxAttempts <= 1 {
+		return 1
+	}
+
+	switch method {
+	case "GET", "DELETE":
+		return p.MaxAttempts
+	case "POST":
+		if p.RetryWrites && isIdempotentWrite(ctx) {
+			return p.MaxAttempts
+		}
+	}
+	return 1
+}
+
+// backoff returns the exponential delay before the given retry, starting at 1
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay
+}
+
+// retryDelay decides if an attempt should be retried, and how long to wait before doing so
+// resp is nil when the attempt failed with a transport error
+func (p *RetryPolicy) retryDelay(retry int, resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		if !p.RetryTransportErrors || !isTransportErrorRetryable(err) {
+			return 0, false
+		}
+		return p.backoff(retry), true
+	}
+
+	retryable := false
+	for _, code := range p.RetryStatusCodes {
+		if resp.StatusCode == code {
+			retryable = true
+			break
+		}
+	}
+	if !retryable {
+		return 0, false
+	}
+
+	if p.RespectRetryAfter {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return p.capDelay(delay), true
+		}
+		// without a Retry-After header a rate limited request waits for the limit to reset
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if rateLimit, ok := parseRateLimit(resp.Header); ok && rateLimit.Exhausted() {
+				return p.capDelay(rateLimit.Reset.Sub(time.Now())), true
+			}
+		}
+	}
+	return p.backoff(retry), true
+}
+
+// capDelay limits a delay asked for by the API to MaxDelay
+// so a far away Retry-After or rate limit reset can't block a request for minutes
+func (p *RetryPolicy) capDelay(delay time.Duration) time.Duration {
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of seconds or an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	delay := date.Sub(time.Now())
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+// isTransportErrorRetryable reports if an error returned by http.Client.Do is worth another attempt
+// Cancelled and expired contexts are never retried
+func isTransportErrorRetryable(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return true
+	}
+	if _, ok := err.(*net.OpError); ok {
+		return true
+	}
+
+	return strings.Contains(err.Error(), "connection reset")
+}
+
+// sleepContext waits for the delay to pass or for the context to be done
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type idempotentWriteKey struct{}
+
+// withIdempotentWrite marks a POST request as safe to retry
+// it is used for writes which the API de-duplicates, like activities with a ForeignID
+func withIdempotentWrite(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentWriteKey{}, true)
+}
+
+func isIdempotentWrite(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(idempotentWriteKey{}).(bool)
+	return idempotent
+}
+
+// allHaveForeignID reports if every activity in a batch carries a ForeignID
+func allHaveForeignID(activities []*Activity) bool {
+	if len(activities) == 0 {
+		return false
+	}
+	for _, activity := range activities {
+		if activity.ForeignID == "" {
+			return false
+		}
+	}
+	return true
+}
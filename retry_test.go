@@ -0,0 +1,158 @@
+package getstream_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+func newFlakyServer(failures int32, status int, retryAfter string) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code": 0, "exception": "Flaky"}`))
+			return
+		}
+		w.Write([]byte(`{"duration": "1ms", "results": [], "activities": []}`))
+	}))
+	return server, &calls
+}
+
+func TestRetryGetOnServerError(t *testing.T) {
+	server, calls := newFlakyServer(2, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	policy := getstream.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := newServerTestClient(t, server.URL, getstream.WithRetryPolicy(policy))
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 3 {
+		t.Fatal("Expected 3 attempts, got:", *calls)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	server, calls := newFlakyServer(10, http.StatusBadGateway, "")
+	defer server.Close()
+
+	policy := getstream.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := newServerTestClient(t, server.URL, getstream.WithRetryPolicy(policy))
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if err == nil {
+		t.Fatal("Expected the last error to be returned")
+	}
+	if *calls != 3 {
+		t.Fatal("Expected 3 attempts, got:", *calls)
+	}
+}
+
+func TestRetryDisabledByDefault(t *testing.T) {
+	server, calls := newFlakyServer(1, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	client := newServerTestClient(t, server.URL, getstream.WithRetryPolicy(nil))
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if err == nil {
+		t.Fatal("Expected an error without a retry policy")
+	}
+	if *calls != 1 {
+		t.Fatal("Expected a single attempt, got:", *calls)
+	}
+}
+
+func TestRetryWrites(t *testing.T) {
+	server, calls := newFlakyServer(1, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	policy := getstream.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	client := newServerTestClient(t, server.URL, getstream.WithRetryPolicy(policy))
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// writes are not retried unless the policy opts in
+	_, err = feed.AddActivity(&getstream.Activity{Verb: "post", Actor: "flat:john", Object: "flat:eric", ForeignID: "post:1"})
+	if err == nil {
+		t.Fatal("Expected an error when RetryWrites is disabled")
+	}
+
+	atomic.StoreInt32(calls, 0)
+	client.Retry.RetryWrites = true
+
+	// writes without a ForeignID cannot be de-duplicated and are never retried
+	_, err = feed.AddActivity(&getstream.Activity{Verb: "post", Actor: "flat:john", Object: "flat:eric"})
+	if err == nil {
+		t.Fatal("Expected an error for an activity without ForeignID")
+	}
+
+	atomic.StoreInt32(calls, 0)
+
+	_, err = feed.AddActivity(&getstream.Activity{Verb: "post", Actor: "flat:john", Object: "flat:eric", ForeignID: "post:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 2 {
+		t.Fatal("Expected 2 attempts, got:", *calls)
+	}
+}
+
+func TestRetryRespectsRetryAfter(t *testing.T) {
+	server, calls := newFlakyServer(1, http.StatusTooManyRequests, "1")
+	defer server.Close()
+
+	// the server asks for a second, MaxDelay caps the wait well below it
+	policy := getstream.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 50 * time.Millisecond
+	client := newServerTestClient(t, server.URL, getstream.WithRetryPolicy(policy))
+
+	feed, err := client.FlatFeed("flat", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = feed.Activities(&getstream.GetFlatFeedInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 2 {
+		t.Fatal("Expected 2 attempts, got:", *calls)
+	}
+	if elapsed := time.Since(start); elapsed < policy.MaxDelay || elapsed >= time.Second {
+		t.Fatal("Expected the client to wait for the Retry-After delay capped at MaxDelay, waited:", elapsed)
+	}
+}
@@ -1,6 +1,13 @@
 package getstream
 
+import (
+	"errors"
+	"strconv"
+)
+
 // ScopeAction defines the Actions allowed by a scope token
+// Actions are bits, they combine into a set with |, like ScopeActionRead | ScopeActionDelete, for checks with Has;
+// a token grants a single action or ScopeActionAll
 type ScopeAction uint32
 
 const (
@@ -14,23 +21,67 @@ const (
 	ScopeActionAll ScopeAction = 8
 )
 
-// Value returns a string representation
+// scopeActions are the single actions, which ParseScopeAction reads
+var scopeActions = []ScopeAction{ScopeActionRead, ScopeActionWrite, ScopeActionDelete}
+
+// Value returns the string representation sent in the action claim of a token
+// The API takes a single action, a set including ScopeActionAll is "*"; other combined sets,
+// the empty set and unknown bits have no representation and return ""
 func (a ScopeAction) Value() string {
+	if a == 0 || a&^(ScopeActionRead|ScopeActionWrite|ScopeActionDelete|ScopeActionAll) != 0 {
+		return ""
+	}
+	if a&ScopeActionAll != 0 {
+		return "*"
+	}
+	return a.name()
+}
+
+// String returns the Value of the set
+func (a ScopeAction) String() string {
+	return a.Value()
+}
+
+// Has reports if the set grants every action of action, a set including ScopeActionAll grants them all
+func (a ScopeAction) Has(action ScopeAction) bool {
+	if action == 0 {
+		return false
+	}
+	return a&ScopeActionAll != 0 || a&action == action
+}
+
+// combined reports if the set holds several actions without ScopeActionAll
+func (a ScopeAction) combined() bool {
+	return a&^(ScopeActionRead|ScopeActionWrite|ScopeActionDelete) == 0 && a&(a-1) != 0
+}
+
+func (a ScopeAction) name() string {
 	switch a {
-	case 1:
+	case ScopeActionRead:
 		return "read"
-	case 2:
+	case ScopeActionWrite:
 		return "write"
-	case 4:
+	case ScopeActionDelete:
 		return "delete"
-	case 8:
+	case ScopeActionAll:
 		return "*"
-	default:
-		return ""
 	}
+	return ""
+}
+
+// ParseScopeAction returns the ScopeAction matching the action claim of a token, like "read" or "*"
+func ParseScopeAction(value string) (ScopeAction, error) {
+	for _, action := range append(scopeActions, ScopeActionAll) {
+		if action.name() == value {
+			return action, nil
+		}
+	}
+	return 0, errors.New("unknown scope action " + strconv.Quote(value))
 }
 
 // ScopeContext defines the resources accessible by a scope token
+// Contexts are bits, they combine into a set with |, like ScopeContextFeed | ScopeContextFollower, for checks with Has;
+// a token grants a single context or ScopeContextAll
 type ScopeContext uint32
 
 const (
@@ -44,18 +95,60 @@ const (
 	ScopeContextAll ScopeContext = 8
 )
 
-// Value returns a string representation
+// scopeContexts are the single contexts, which ParseScopeContext reads
+var scopeContexts = []ScopeContext{ScopeContextActivities, ScopeContextFeed, ScopeContextFollower}
+
+// Value returns the string representation sent in the resource claim of a token
+// The API takes a single resource, a set including ScopeContextAll is "*"; other combined sets,
+// the empty set and unknown bits have no representation and return ""
 func (a ScopeContext) Value() string {
+	if a == 0 || a&^(ScopeContextActivities|ScopeContextFeed|ScopeContextFollower|ScopeContextAll) != 0 {
+		return ""
+	}
+	if a&ScopeContextAll != 0 {
+		return "*"
+	}
+	return a.name()
+}
+
+// String returns the Value of the set
+func (a ScopeContext) String() string {
+	return a.Value()
+}
+
+// Has reports if the set grants every resource of context, a set including ScopeContextAll grants them all
+func (a ScopeContext) Has(context ScopeContext) bool {
+	if context == 0 {
+		return false
+	}
+	return a&ScopeContextAll != 0 || a&context == context
+}
+
+// combined reports if the set holds several contexts without ScopeContextAll
+func (a ScopeContext) combined() bool {
+	return a&^(ScopeContextActivities|ScopeContextFeed|ScopeContextFollower) == 0 && a&(a-1) != 0
+}
+
+func (a ScopeContext) name() string {
 	switch a {
-	case 1:
+	case ScopeContextActivities:
 		return "activities"
-	case 2:
+	case ScopeContextFeed:
 		return "feed"
-	case 4:
+	case ScopeContextFollower:
 		return "follower"
-	case 8:
+	case ScopeContextAll:
 		return "*"
-	default:
-		return ""
 	}
+	return ""
+}
+
+// ParseScopeContext returns the ScopeContext matching the resource claim of a token, like "feed" or "*"
+func ParseScopeContext(value string) (ScopeContext, error) {
+	for _, context := range append(scopeContexts, ScopeContextAll) {
+		if context.name() == value {
+			return context, nil
+		}
+	}
+	return 0, errors.New("unknown scope context " + strconv.Quote(value))
 }
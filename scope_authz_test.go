@@ -0,0 +1,123 @@
+package getstream_test
+
+import (
+	"testing"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+func TestParseScope(t *testing.T) {
+	for _, context := range []getstream.ScopeContext{getstream.ScopeContextActivities, getstream.ScopeContextFeed, getstream.ScopeContextFollower, getstream.ScopeContextAll} {
+		parsed, err := getstream.ParseScopeContext(context.Value())
+		if err != nil || parsed != context {
+			t.Error("expected", context.Value(), "to be parsed, got:", parsed, err)
+		}
+	}
+	for _, action := range []getstream.ScopeAction{getstream.ScopeActionRead, getstream.ScopeActionWrite, getstream.ScopeActionDelete, getstream.ScopeActionAll} {
+		parsed, err := getstream.ParseScopeAction(action.Value())
+		if err != nil || parsed != action {
+			t.Error("expected", action.Value(), "to be parsed, got:", parsed, err)
+		}
+	}
+
+	if _, err := getstream.ParseScopeContext("users"); err == nil {
+		t.Error("expected an unknown context to be rejected")
+	}
+	if _, err := getstream.ParseScopeAction("update"); err == nil {
+		t.Error("expected an unknown action to be rejected")
+	}
+}
+
+func TestScopeSetValue(t *testing.T) {
+	for set, expected := range map[getstream.ScopeAction]string{
+		getstream.ScopeActionRead:                                                            "read",
+		getstream.ScopeActionRead | getstream.ScopeActionDelete:                              "",
+		getstream.ScopeActionDelete | getstream.ScopeActionWrite | getstream.ScopeActionRead: "",
+		getstream.ScopeActionRead | getstream.ScopeActionAll:                                 "*",
+		0:  "",
+		16: "",
+	} {
+		if set.Value() != expected {
+			t.Error("expected", expected, "got", set.Value())
+		}
+	}
+
+	for set, expected := range map[getstream.ScopeContext]string{
+		getstream.ScopeContextFeed:                                   "feed",
+		getstream.ScopeContextFeed | getstream.ScopeContextFollower:  "",
+		getstream.ScopeContextActivities | getstream.ScopeContextAll: "*",
+		getstream.ScopeContextFeed | 32:                              "",
+	} {
+		if set.Value() != expected {
+			t.Error("expected", expected, "got", set.Value())
+		}
+	}
+}
+
+func TestScopeSetHas(t *testing.T) {
+	readDelete := getstream.ScopeActionRead | getstream.ScopeActionDelete
+	if !readDelete.Has(getstream.ScopeActionRead) || !readDelete.Has(getstream.ScopeActionDelete) || !readDelete.Has(readDelete) {
+		t.Error("expected read,delete to have read and delete")
+	}
+	if readDelete.Has(getstream.ScopeActionWrite) || readDelete.Has(getstream.ScopeActionRead|getstream.ScopeActionWrite) {
+		t.Error("expected read,delete not to have write")
+	}
+	if !getstream.ScopeActionAll.Has(readDelete) || readDelete.Has(0) {
+		t.Error("expected * to have every action and no set to have the empty set")
+	}
+
+	feedFollower := getstream.ScopeContextFeed | getstream.ScopeContextFollower
+	if !feedFollower.Has(getstream.ScopeContextFollower) || feedFollower.Has(getstream.ScopeContextActivities) {
+		t.Error("unexpected membership of feed,follower")
+	}
+	if !getstream.ScopeContextAll.Has(feedFollower) {
+		t.Error("expected * to have every context")
+	}
+}
+
+func TestParseScopeSets(t *testing.T) {
+	if _, err := getstream.ParseScopeAction("read,delete"); err == nil {
+		t.Error("expected a list of actions to be rejected")
+	}
+	if _, err := getstream.ParseScopeContext("feed,follower"); err == nil {
+		t.Error("expected a list of contexts to be rejected")
+	}
+}
+
+func TestScopeSetTokens(t *testing.T) {
+	signer := getstream.Signer{Secret: "my_secret"}
+
+	if _, err := signer.NewToken(getstream.ScopeContextFeed|getstream.ScopeContextFollower, getstream.ScopeActionRead).Sign(); err == nil {
+		t.Error("expected a combined resource to be rejected")
+	}
+	if _, err := signer.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead|getstream.ScopeActionDelete).Sign(); err == nil {
+		t.Error("expected a combined action to be rejected")
+	}
+
+	tokenString, err := signer.NewToken(
+		getstream.ScopeContextAll|getstream.ScopeContextFeed,
+		getstream.ScopeActionRead,
+	).Feed("userbob").Sign()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := signer.VerifyToken(tokenString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		context  getstream.ScopeContext
+		action   getstream.ScopeAction
+		expected bool
+	}{
+		{getstream.ScopeContextFeed, getstream.ScopeActionRead, true},
+		{getstream.ScopeContextFeed | getstream.ScopeContextFollower, getstream.ScopeActionRead, true},
+		{getstream.ScopeContextFollower, getstream.ScopeActionRead | getstream.ScopeActionDelete, false},
+		{getstream.ScopeContextFeed, getstream.ScopeActionWrite, false},
+	} {
+		if claims.Permits(test.context, test.action, "userbob") != test.expected {
+			t.Error(test.context, test.action, "expected", test.expected)
+		}
+	}
+}
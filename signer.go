@@ -4,16 +4,40 @@ import (
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
+	"errors"
 	"strings"
-
-	"gopkg.in/dgrijalva/jwt-go.v3"
 )
 
 // Credits to https://github.com/hyperworks/go-getstream for the urlSafe and generateToken methods
 
 // Signer is responsible for generating Tokens
+// Tokens are signed with Secret, and verified against Secret and SecondarySecrets, so a secret can be rotated
+// without downtime: the new secret becomes Secret while the old one stays in SecondarySecrets until the
+// tokens it signed have expired
 type Signer struct {
-	Secret string
+	Secret           string
+	SecondarySecrets []string
+}
+
+// signerSecret is a secret tokens are verified against, index is 0 for Signer.Secret and i+1 for Signer.SecondarySecrets[i]
+type signerSecret struct {
+	index  int
+	secret string
+}
+
+// errNoSecret is returned when verifying tokens with a Signer without a Secret
+var errNoSecret = errors.New("the signer has no secret to verify tokens with")
+
+// secrets returns every secret tokens are verified against, Secret first
+// Empty secrets are left out, they would accept any token signed with an empty key
+func (s Signer) secrets() []signerSecret {
+	var secrets []signerSecret
+	for i, secret := range append([]string{s.Secret}, s.SecondarySecrets...) {
+		if secret != "" {
+			secrets = append(secrets, signerSecret{index: i, secret: secret})
+		}
+	}
+	return secrets
 }
 
 // SignFeed sets the token on a Feed
@@ -39,64 +63,32 @@ func (s Signer) GenerateToken(message string) string {
 	return s.UrlSafe(digest)
 }
 
-// GenerateFeedScopeToken returns a jwt
-func (s Signer) GenerateFeedScopeToken(context ScopeContext, action ScopeAction, feedIDWithoutColon string) (string, error) {
-
-	claims := jwt.MapClaims{
-		"resource": context.Value(),
-		"action":   action.Value(),
-		// "aud":
-		// "exp": time.Now().UTC().Add(time.Hour * 1),
-		// "jti": uuid.New(),
-		// "iat": time.Now(),
-		// "iss":
-		// "nbf": time.Now().Unix(),
-		// "sub":
+// VerifyFeedToken checks token is the token GenerateToken returns for a feed with any of the secrets of the signer
+// It returns the index of the secret which made the token, like TokenClaims.SecretIndex
+func (s Signer) VerifyFeedToken(feedIDWithoutColon string, token string) (int, error) {
+	if s.Secret == "" {
+		return 0, &TokenError{Err: errNoSecret}
 	}
-
-	if feedIDWithoutColon != "" {
-		claims["feed_id"] = feedIDWithoutColon
-	} else {
-		claims["feed_id"] = "*"
+	for _, secret := range s.secrets() {
+		expected := Signer{Secret: secret.secret}.GenerateToken(feedIDWithoutColon)
+		if hmac.Equal([]byte(expected), []byte(token)) {
+			return secret.index, nil
+		}
 	}
+	return 0, &TokenError{Err: errors.New("the feed token does not match any secret")}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign and get the complete encoded token as a string using the secret
-	tokenString, err := token.SignedString([]byte(s.Secret))
-	if err != nil {
-		return "", err
+// GenerateFeedScopeToken returns a jwt granting action on context for a feed, or every feed when feedIDWithoutColon is empty
+// The token never expires, use NewToken to build short lived tokens
+func (s Signer) GenerateFeedScopeToken(context ScopeContext, action ScopeAction, feedIDWithoutColon string) (string, error) {
+	if feedIDWithoutColon == "" {
+		feedIDWithoutColon = "*"
 	}
-
-	return tokenString, nil
+	return s.NewToken(context, action).Feed(feedIDWithoutColon).Sign()
 }
 
-// GenerateUserScopeToken returns a jwt
+// GenerateUserScopeToken returns a jwt granting action on context for a user
+// The token never expires, use NewToken to build short lived tokens
 func (s Signer) GenerateUserScopeToken(context ScopeContext, action ScopeAction, userID string) (string, error) {
-
-	claims := jwt.MapClaims{
-		"resource": context.Value(),
-		"action":   action.Value(),
-		// "aud":
-		// "exp": time.Now().UTC().Add(time.Hour * 1),
-		// "jti": uuid.New(),
-		// "iat": time.Now(),
-		// "iss":
-		// "nbf": time.Now().Unix(),
-		// "sub":
-	}
-
-	if userID != "" {
-		claims["user_id"] = userID
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign and get the complete encoded token as a string using the secret
-	tokenString, err := token.SignedString([]byte(s.Secret))
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
+	return s.NewToken(context, action).User(userID).Sign()
 }
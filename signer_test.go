@@ -123,3 +123,23 @@ func TestUserScopeToken(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestVerifyFeedToken(t *testing.T) {
+	signer := getstream.Signer{Secret: "new_secret", SecondarySecrets: []string{"old_secret"}}
+
+	index, err := signer.VerifyFeedToken("userbob", signer.GenerateToken("userbob"))
+	if err != nil || index != 0 {
+		t.Error("expected the primary secret to match, got:", index, err)
+	}
+
+	oldToken := getstream.Signer{Secret: "old_secret"}.GenerateToken("userbob")
+	index, err = signer.VerifyFeedToken("userbob", oldToken)
+	if err != nil || index != 1 {
+		t.Error("expected the secondary secret to match, got:", index, err)
+	}
+
+	_, err = signer.VerifyFeedToken("useralice", oldToken)
+	if !getstream.IsTokenInvalid(err) {
+		t.Error("expected the token of another feed to be rejected, got:", err)
+	}
+}
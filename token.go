@@ -0,0 +1,267 @@
+package getstream
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gopkg.in/dgrijalva/jwt-go.v3"
+)
+
+// TokenBuilder builds a JWT granting an action on a resource, signed with the Secret of a Signer
+//
+//	token, err := signer.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead).
+//		Feed("userbob").
+//		User("bob").
+//		TTL(time.Hour).
+//		Sign()
+//
+// Tokens carry no time claims unless they are set, such tokens never expire.
+type TokenBuilder struct {
+	secret  string
+	context ScopeContext
+	action  ScopeAction
+
+	feedID  string
+	userID  string
+	tokenID string
+
+	issuedAt  time.Time
+	notBefore time.Time
+	expiresAt time.Time
+	ttl       time.Duration
+}
+
+// NewToken starts building a JWT granting action on the context resource
+// context and action are single values or the All values, Sign fails for other combined sets
+func (s Signer) NewToken(context ScopeContext, action ScopeAction) *TokenBuilder {
+	return &TokenBuilder{
+		secret:  s.Secret,
+		context: context,
+		action:  action,
+	}
+}
+
+// Feed restricts the token to a feed, "*" grants access to every feed
+func (b *TokenBuilder) Feed(feedIDWithoutColon string) *TokenBuilder {
+	b.feedID = feedIDWithoutColon
+	return b
+}
+
+// User sets the user_id claim
+func (b *TokenBuilder) User(userID string) *TokenBuilder {
+	b.userID = userID
+	return b
+}
+
+// ID sets the jti claim, a unique identifier of the token
+func (b *TokenBuilder) ID(tokenID string) *TokenBuilder {
+	b.tokenID = tokenID
+	return b
+}
+
+// IssuedAt sets the iat claim
+func (b *TokenBuilder) IssuedAt(issuedAt time.Time) *TokenBuilder {
+	b.issuedAt = issuedAt
+	return b
+}
+
+// NotBefore sets the nbf claim, the token is rejected before that time
+func (b *TokenBuilder) NotBefore(notBefore time.Time) *TokenBuilder {
+	b.notBefore = notBefore
+	return b
+}
+
+// ExpiresAt sets the exp claim, the token is rejected from that time on
+func (b *TokenBuilder) ExpiresAt(expiresAt time.Time) *TokenBuilder {
+	b.expiresAt = expiresAt
+	b.ttl = 0
+	return b
+}
+
+// TTL makes the token expire ttl after it is issued
+// The iat claim is set to the time Sign is called, unless IssuedAt is set
+func (b *TokenBuilder) TTL(ttl time.Duration) *TokenBuilder {
+	b.ttl = ttl
+	b.expiresAt = time.Time{}
+	return b
+}
+
+// Claims returns the claims of the token, as they are signed by Sign
+func (b *TokenBuilder) Claims() (jwt.MapClaims, error) {
+	if b.context.combined() {
+		return nil, errors.New("token resource must be a single ScopeContext or ScopeContextAll, the API does not accept combined resources")
+	}
+	if b.context.Value() == "" {
+		return nil, errors.New("token has no valid resource")
+	}
+	if b.action.combined() {
+		return nil, errors.New("token action must be a single ScopeAction or ScopeActionAll, the API does not accept combined actions")
+	}
+	if b.action.Value() == "" {
+		return nil, errors.New("token has no valid action")
+	}
+	if b.ttl < 0 {
+		return nil, errors.New("token TTL must be positive")
+	}
+
+	claims := jwt.MapClaims{
+		"resource": b.context.Value(),
+		"action":   b.action.Value(),
+	}
+	if b.feedID != "" {
+		claims["feed_id"] = b.feedID
+	}
+	if b.userID != "" {
+		claims["user_id"] = b.userID
+	}
+	if b.tokenID != "" {
+		claims["jti"] = b.tokenID
+	}
+
+	issuedAt := b.issuedAt
+	if issuedAt.IsZero() && b.ttl > 0 {
+		issuedAt = time.Now()
+	}
+	expiresAt := b.expiresAt
+	if b.ttl > 0 {
+		expiresAt = issuedAt.Add(b.ttl)
+	}
+
+	if !issuedAt.IsZero() {
+		claims["iat"] = issuedAt.Unix()
+	}
+	if !b.notBefore.IsZero() {
+		claims["nbf"] = b.notBefore.Unix()
+	}
+	if !expiresAt.IsZero() {
+		if !b.notBefore.IsZero() && !expiresAt.After(b.notBefore) {
+			return nil, errors.New("token expires before it is valid")
+		}
+		claims["exp"] = expiresAt.Unix()
+	}
+
+	return claims, nil
+}
+
+// Sign returns the signed JWT
+func (b *TokenBuilder) Sign() (string, error) {
+	claims, err := b.Claims()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// Sign and get the complete encoded token as a string using the secret
+	return token.SignedString([]byte(b.secret))
+}
+
+// TokenClaims are the claims of a JWT verified by Signer.VerifyToken
+type TokenClaims struct {
+	Context ScopeContext
+	Action  ScopeAction
+	// FeedID is the feed the token is restricted to without its colon, "*" for every feed,
+	// and empty for tokens which are not bound to a feed
+	FeedID  string
+	UserID  string
+	TokenID string
+
+	// the time claims are zero when they are not set, a token without ExpiresAt never expires
+	IssuedAt  time.Time
+	NotBefore time.Time
+	ExpiresAt time.Time
+
+	// SecretIndex is the secret which verified the token: 0 for Signer.Secret, i+1 for Signer.SecondarySecrets[i]
+	SecretIndex int
+}
+
+// Permits reports if the claims grant action on the context resource of a feed
+// context and action can be sets, every resource and action of them must be granted.
+// An empty feedIDWithoutColon checks the resource and action only
+func (c *TokenClaims) Permits(context ScopeContext, action ScopeAction, feedIDWithoutColon string) bool {
+	if !c.Context.Has(context) || !c.Action.Has(action) {
+		return false
+	}
+	return feedIDWithoutColon == "" || c.FeedID == "*" || c.FeedID == feedIDWithoutColon
+}
+
+// VerifyToken checks a JWT is signed with one of the secrets of the signer and is valid at the current time, and returns its claims
+// Failures are reported as a *TokenError, matching ErrTokenInvalid and, for expired tokens, ErrTokenExpired
+func (s Signer) VerifyToken(tokenString string) (*TokenClaims, error) {
+	mapClaims, secretIndex, err := s.parseToken(tokenString)
+	if err != nil {
+		tokenErr := &TokenError{Err: err}
+		// jwt-go validates the claims before the signature, an expired token is only reported as such when it is genuine
+		if validationErr, ok := err.(*jwt.ValidationError); ok &&
+			validationErr.Errors&jwt.ValidationErrorExpired != 0 && validationErr.Errors&jwt.ValidationErrorSignatureInvalid == 0 {
+			tokenErr.expired = true
+		}
+		return nil, tokenErr
+	}
+
+	claims := &TokenClaims{SecretIndex: secretIndex}
+	resource, _ := mapClaims["resource"].(string)
+	if claims.Context, err = ParseScopeContext(resource); err != nil {
+		return nil, &TokenError{Err: err}
+	}
+	action, _ := mapClaims["action"].(string)
+	if claims.Action, err = ParseScopeAction(action); err != nil {
+		return nil, &TokenError{Err: err}
+	}
+
+	claims.FeedID, _ = mapClaims["feed_id"].(string)
+	claims.UserID, _ = mapClaims["user_id"].(string)
+	claims.TokenID, _ = mapClaims["jti"].(string)
+	claims.IssuedAt = claimTime(mapClaims, "iat")
+	claims.NotBefore = claimTime(mapClaims, "nbf")
+	claims.ExpiresAt = claimTime(mapClaims, "exp")
+
+	return claims, nil
+}
+
+// parseToken parses a JWT, trying the secrets of the signer in turn until one verifies its signature
+// It returns the claims and the index of that secret
+func (s Signer) parseToken(tokenString string) (jwt.MapClaims, int, error) {
+	if s.Secret == "" {
+		return nil, 0, errNoSecret
+	}
+
+	var err error
+	for _, secret := range s.secrets() {
+		mapClaims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(tokenString, mapClaims, func(token *jwt.Token) (interface{}, error) {
+			// only accept the HMAC methods tokens are signed with, never the algorithm picked by the token
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("unexpected signing method " + token.Method.Alg())
+			}
+			return []byte(secret.secret), nil
+		})
+		if validationErr, ok := err.(*jwt.ValidationError); ok && validationErr.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+			continue
+		}
+		return mapClaims, secret.index, err
+	}
+	return nil, 0, err
+}
+
+// TokenPermits verifies a JWT and reports if it grants action on the context resource of a feed
+func (s Signer) TokenPermits(tokenString string, context ScopeContext, action ScopeAction, feedIDWithoutColon string) (bool, error) {
+	claims, err := s.VerifyToken(tokenString)
+	if err != nil {
+		return false, err
+	}
+	return claims.Permits(context, action, feedIDWithoutColon), nil
+}
+
+// claimTime reads a NumericDate claim, the zero time is returned when it is not set
+func claimTime(claims jwt.MapClaims, name string) time.Time {
+	switch value := claims[name].(type) {
+	case float64:
+		return time.Unix(int64(value), 0)
+	case json.Number:
+		seconds, _ := value.Int64()
+		return time.Unix(seconds, 0)
+	}
+	return time.Time{}
+}
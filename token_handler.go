@@ -0,0 +1,195 @@
+package getstream
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultTokenTTL is how long the JWTs issued by a TokenHandler are valid when it has no TTL
+const defaultTokenTTL = time.Hour
+
+// TokenHandler is an http.Handler issuing short lived JWTs to frontend clients, for the feeds of the user making the request
+//
+//	http.Handle("/stream/tokens", getstream.NewTokenHandler(*client.Signer, func(r *http.Request) (string, error) {
+//		return sessionUserID(r)
+//	}, "user", "timeline", "notification"))
+//
+// A GET request returns a TokenResponse with a token for the feed of the user in every feed group of FeedGroups.
+// The feed_groups query param, like ?feed_groups=user,timeline, narrows the response to some of the groups.
+type TokenHandler struct {
+	Signer Signer
+
+	// Authenticate returns the id of the user making the request, or an error when the request is not authenticated
+	Authenticate func(r *http.Request) (string, error)
+
+	// FeedGroups are the feed groups tokens can be issued for
+	FeedGroups []string
+
+	// Context and Action are the scope of the JWTs, ScopeContextFeed and ScopeActionRead when zero
+	Context ScopeContext
+	Action  ScopeAction
+
+	// TTL is how long the JWTs are valid, an hour when zero
+	TTL time.Duration
+
+	// IncludeFeedTokens adds the feed tokens made by Signer.GenerateToken to the response
+	// Feed tokens never expire and grant every action on their feed, leave them out unless the clients need them
+	IncludeFeedTokens bool
+}
+
+// NewTokenHandler returns a TokenHandler issuing read only JWTs valid for an hour, for the feeds of the authenticated user
+// in the given feed groups
+func NewTokenHandler(signer Signer, authenticate func(r *http.Request) (string, error), feedGroups ...string) *TokenHandler {
+	return &TokenHandler{
+		Signer:       signer,
+		Authenticate: authenticate,
+		FeedGroups:   feedGroups,
+		Context:      ScopeContextFeed,
+		Action:       ScopeActionRead,
+		TTL:          defaultTokenTTL,
+	}
+}
+
+// TokenResponse is the JSON body returned by a TokenHandler
+type TokenResponse struct {
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Feeds are keyed by feed id, like "user:bob"
+	Feeds map[string]FeedToken `json:"feeds"`
+}
+
+// FeedToken holds the tokens issued for a feed
+type FeedToken struct {
+	FeedID string `json:"feed_id"`
+	JWT    string `json:"jwt"`
+	// Token is the feed token, only set when TokenHandler.IncludeFeedTokens is
+	Token string `json:"token,omitempty"`
+}
+
+// ServeHTTP implements http.Handler
+func (h *TokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeTokenError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if h.Authenticate == nil {
+		writeTokenError(w, http.StatusInternalServerError, "token handler has no Authenticate function")
+		return
+	}
+	userID, err := h.Authenticate(r)
+	if err != nil || userID == "" {
+		writeTokenError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	userID, err = ValidateUserID(userID)
+	if err != nil {
+		writeTokenError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	feedGroups, err := h.requestedFeedGroups(r)
+	if err != nil {
+		writeTokenError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	response, err := h.issue(userID, feedGroups)
+	if err != nil {
+		writeTokenError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
+
+// requestedFeedGroups returns the feed groups named by the feed_groups query param, or all of FeedGroups
+func (h *TokenHandler) requestedFeedGroups(r *http.Request) ([]string, error) {
+	requested := r.URL.Query().Get("feed_groups")
+	if requested == "" {
+		return h.FeedGroups, nil
+	}
+
+	var feedGroups []string
+	for _, feedGroup := range strings.Split(requested, ",") {
+		feedGroup = strings.TrimSpace(feedGroup)
+		if !h.allows(feedGroup) {
+			return nil, errors.New("tokens are not issued for feed group " + feedGroup)
+		}
+		feedGroups = append(feedGroups, feedGroup)
+	}
+	return feedGroups, nil
+}
+
+// allows reports if feedGroup is one of FeedGroups
+func (h *TokenHandler) allows(feedGroup string) bool {
+	for _, allowed := range h.FeedGroups {
+		if allowed == feedGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// issue builds the tokens of a user for the feed groups
+func (h *TokenHandler) issue(userID string, feedGroups []string) (*TokenResponse, error) {
+	context := h.Context
+	if context == 0 {
+		context = ScopeContextFeed
+	}
+	action := h.Action
+	if action == 0 {
+		action = ScopeActionRead
+	}
+	ttl := h.TTL
+	if ttl <= 0 {
+		ttl = defaultTokenTTL
+	}
+
+	issuedAt := time.Now()
+	response := &TokenResponse{
+		UserID:    userID,
+		ExpiresAt: issuedAt.Add(ttl).UTC().Truncate(time.Second),
+		Feeds:     make(map[string]FeedToken),
+	}
+
+	for _, feedGroup := range feedGroups {
+		feedSlug, err := ValidateFeedSlug(feedGroup)
+		if err != nil {
+			return nil, err
+		}
+
+		feedToken := FeedToken{
+			FeedID: feedSlug + ":" + userID,
+		}
+		feedToken.JWT, err = h.Signer.NewToken(context, action).
+			Feed(feedSlug + userID).
+			User(userID).
+			IssuedAt(issuedAt).
+			TTL(ttl).
+			Sign()
+		if err != nil {
+			return nil, err
+		}
+		if h.IncludeFeedTokens {
+			feedToken.Token = h.Signer.GenerateToken(feedSlug + userID)
+		}
+
+		response.Feeds[feedToken.FeedID] = feedToken
+	}
+
+	return response, nil
+}
+
+// writeTokenError writes a JSON error body
+func writeTokenError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,132 @@
+package getstream_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	getstream "github.com/GetStream/stream-go"
+)
+
+func newTestTokenHandler() *getstream.TokenHandler {
+	return getstream.NewTokenHandler(getstream.Signer{Secret: "my_secret"}, func(r *http.Request) (string, error) {
+		userID := r.Header.Get("X-User")
+		if userID == "" {
+			return "", errors.New("no session")
+		}
+		return userID, nil
+	}, "user", "timeline")
+}
+
+func serveTokenRequest(handler http.Handler, method string, target string, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if userID != "" {
+		req.Header.Set("X-User", userID)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestTokenHandler(t *testing.T) {
+	handler := newTestTokenHandler()
+	handler.TTL = 10 * time.Minute
+
+	before := time.Now()
+	recorder := serveTokenRequest(handler, "GET", "/tokens", "bob")
+	if recorder.Code != http.StatusOK {
+		t.Fatal("expected a 200, got", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Cache-Control") != "no-store" {
+		t.Error("expected the response not to be cached")
+	}
+
+	var response getstream.TokenResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.UserID != "bob" || len(response.Feeds) != 2 {
+		t.Fatal("unexpected response:", response)
+	}
+	if response.ExpiresAt.Before(before.Add(9*time.Minute)) || response.ExpiresAt.After(before.Add(11*time.Minute)) {
+		t.Error("expected the tokens to expire in 10 minutes, got", response.ExpiresAt)
+	}
+
+	feedToken := response.Feeds["timeline:bob"]
+	if feedToken.FeedID != "timeline:bob" || feedToken.Token != "" {
+		t.Error("unexpected feed token:", feedToken)
+	}
+
+	claims, err := handler.Signer.VerifyToken(feedToken.JWT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.Permits(getstream.ScopeContextFeed, getstream.ScopeActionRead, "timelinebob") {
+		t.Error("expected the JWT to permit reading the feed")
+	}
+	if claims.Permits(getstream.ScopeContextFeed, getstream.ScopeActionWrite, "timelinebob") {
+		t.Error("expected the JWT to be read only")
+	}
+	if claims.Permits(getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob") {
+		t.Error("expected the JWT to be bound to its feed")
+	}
+	if claims.UserID != "bob" || !claims.ExpiresAt.Equal(response.ExpiresAt) {
+		t.Error("unexpected claims:", claims)
+	}
+}
+
+func TestTokenHandlerFeedGroups(t *testing.T) {
+	handler := newTestTokenHandler()
+
+	recorder := serveTokenRequest(handler, "GET", "/tokens?feed_groups=user", "bob")
+	var response getstream.TokenResponse
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	if _, ok := response.Feeds["user:bob"]; !ok || len(response.Feeds) != 1 {
+		t.Error("expected the user feed only, got:", response.Feeds)
+	}
+
+	recorder = serveTokenRequest(handler, "GET", "/tokens?feed_groups=user,admin", "bob")
+	if recorder.Code != http.StatusForbidden {
+		t.Error("expected a feed group outside the allow list to be refused, got", recorder.Code)
+	}
+}
+
+func TestTokenHandlerFeedTokens(t *testing.T) {
+	handler := newTestTokenHandler()
+	handler.IncludeFeedTokens = true
+
+	recorder := serveTokenRequest(handler, "GET", "/tokens", "bob")
+	var response getstream.TokenResponse
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+
+	if response.Feeds["user:bob"].Token != handler.Signer.GenerateToken("userbob") {
+		t.Error("expected the feed token, got:", response.Feeds["user:bob"])
+	}
+}
+
+func TestTokenHandlerErrors(t *testing.T) {
+	handler := newTestTokenHandler()
+
+	for _, test := range []struct {
+		method string
+		userID string
+		status int
+	}{
+		{"GET", "", http.StatusUnauthorized},
+		{"POST", "bob", http.StatusMethodNotAllowed},
+		{"GET", "bob/../alice", http.StatusBadRequest},
+	} {
+		recorder := serveTokenRequest(handler, test.method, "/tokens", test.userID)
+		if recorder.Code != test.status {
+			t.Error(test.method, test.userID, "expected", test.status, "got", recorder.Code)
+		}
+
+		var body map[string]string
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || body["error"] == "" {
+			t.Error("expected a JSON error, got:", recorder.Body.String())
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package getstream_test
+
+import (
+	"testing"
+	"time"
+
+	getstream "github.com/GetStream/stream-go"
+	"gopkg.in/dgrijalva/jwt-go.v3"
+)
+
+func parseTestToken(t *testing.T, tokenString string, secret string) jwt.MapClaims {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token.Claims.(jwt.MapClaims)
+}
+
+func TestTokenBuilder(t *testing.T) {
+	signer := getstream.Signer{Secret: "my_secret"}
+
+	issuedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	notBefore := issuedAt.Add(30 * time.Second)
+	tokenString, err := signer.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead).
+		Feed("userbob").
+		User("bob").
+		ID("token-1").
+		IssuedAt(issuedAt).
+		NotBefore(notBefore).
+		TTL(time.Hour).
+		Sign()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := parseTestToken(t, tokenString, "my_secret")
+	for name, expected := range map[string]interface{}{
+		"resource": "feed",
+		"action":   "read",
+		"feed_id":  "userbob",
+		"user_id":  "bob",
+		"jti":      "token-1",
+		"iat":      float64(issuedAt.Unix()),
+		"nbf":      float64(notBefore.Unix()),
+		"exp":      float64(issuedAt.Add(time.Hour).Unix()),
+	} {
+		if claims[name] != expected {
+			t.Error("expected the", name, "claim to be", expected, "got", claims[name])
+		}
+	}
+}
+
+func TestTokenBuilderTTL(t *testing.T) {
+	signer := getstream.Signer{Secret: "my_secret"}
+
+	before := time.Now().Unix()
+	tokenString, err := signer.NewToken(getstream.ScopeContextAll, getstream.ScopeActionAll).Feed("*").TTL(time.Minute).Sign()
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now().Unix()
+
+	claims := parseTestToken(t, tokenString, "my_secret")
+	issuedAt := int64(claims["iat"].(float64))
+	if issuedAt < before || issuedAt > after {
+		t.Error("expected the token to be issued now, got", issuedAt)
+	}
+	if int64(claims["exp"].(float64)) != issuedAt+60 {
+		t.Error("expected the token to expire a minute after it is issued, got", claims["exp"])
+	}
+	if _, ok := claims["nbf"]; ok {
+		t.Error("expected no nbf claim")
+	}
+}
+
+func TestTokenBuilderExpired(t *testing.T) {
+	signer := getstream.Signer{Secret: "my_secret"}
+
+	tokenString, err := signer.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead).
+		Feed("userbob").
+		ExpiresAt(time.Now().Add(-time.Minute)).
+		Sign()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte("my_secret"), nil
+	})
+	if err == nil {
+		t.Error("expected an expired token to be rejected")
+	}
+}
+
+func TestTokenBuilderErrors(t *testing.T) {
+	signer := getstream.Signer{Secret: "my_secret"}
+	now := time.Now()
+
+	for name, builder := range map[string]*getstream.TokenBuilder{
+		"no resource":       signer.NewToken(0, getstream.ScopeActionRead),
+		"no action":         signer.NewToken(getstream.ScopeContextFeed, 0),
+		"negative TTL":      signer.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead).TTL(-time.Minute),
+		"expires too early": signer.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead).NotBefore(now).ExpiresAt(now),
+	} {
+		if _, err := builder.Sign(); err == nil {
+			t.Error(name, "expected an error")
+		}
+	}
+}
+
+func TestGenerateScopeTokensNeverExpire(t *testing.T) {
+	signer := getstream.Signer{Secret: "my_secret"}
+
+	tokenString, err := signer.GenerateFeedScopeToken(getstream.ScopeContextFeed, getstream.ScopeActionRead, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := parseTestToken(t, tokenString, "my_secret")
+	if claims["feed_id"] != "*" {
+		t.Error("expected an empty feed id to grant every feed, got", claims["feed_id"])
+	}
+	if _, ok := claims["exp"]; ok {
+		t.Error("expected no exp claim")
+	}
+
+	tokenString, err = signer.GenerateUserScopeToken(getstream.ScopeContextFeed, getstream.ScopeActionRead, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims = parseTestToken(t, tokenString, "my_secret")
+	if claims["user_id"] != "bob" {
+		t.Error("expected the user_id claim, got", claims["user_id"])
+	}
+	if _, ok := claims["feed_id"]; ok {
+		t.Error("expected no feed_id claim")
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	signer := getstream.Signer{Secret: "my_secret"}
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	tokenString, err := signer.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionWrite).
+		Feed("userbob").
+		User("bob").
+		ID("token-1").
+		ExpiresAt(expiresAt).
+		Sign()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := signer.VerifyToken(tokenString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Context != getstream.ScopeContextFeed || claims.Action != getstream.ScopeActionWrite {
+		t.Error("unexpected scope:", claims.Context, claims.Action)
+	}
+	if claims.FeedID != "userbob" || claims.UserID != "bob" || claims.TokenID != "token-1" {
+		t.Error("unexpected claims:", claims)
+	}
+	if !claims.ExpiresAt.Equal(expiresAt) || !claims.IssuedAt.IsZero() || !claims.NotBefore.IsZero() {
+		t.Error("unexpected time claims:", claims.IssuedAt, claims.NotBefore, claims.ExpiresAt)
+	}
+}
+
+func TestVerifyTokenErrors(t *testing.T) {
+	signer := getstream.Signer{Secret: "my_secret"}
+
+	expired, _ := signer.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead).ExpiresAt(time.Now().Add(-time.Minute)).Sign()
+	_, err := signer.VerifyToken(expired)
+	if !getstream.IsTokenExpired(err) || !getstream.IsTokenInvalid(err) {
+		t.Error("expected an expired token error, got:", err)
+	}
+
+	forgedExpired, _ := getstream.Signer{Secret: "other_secret"}.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead).ExpiresAt(time.Now().Add(-time.Minute)).Sign()
+	_, err = signer.VerifyToken(forgedExpired)
+	if !getstream.IsTokenInvalid(err) || getstream.IsTokenExpired(err) {
+		t.Error("expected a forged expired token to be invalid and not expired, got:", err)
+	}
+
+	notYet, _ := signer.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead).NotBefore(time.Now().Add(time.Hour)).Sign()
+	otherSecret, _ := getstream.Signer{Secret: "other_secret"}.GenerateFeedScopeToken(getstream.ScopeContextFeed, getstream.ScopeActionRead, "")
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"resource": "*", "action": "*"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	unknownResource, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"resource": "users", "action": "*"}).SignedString([]byte("my_secret"))
+
+	for name, tokenString := range map[string]string{
+		"not valid yet":    notYet,
+		"other secret":     otherSecret,
+		"unsigned":         unsigned,
+		"unknown resource": unknownResource,
+		"malformed":        "not.a.token",
+	} {
+		_, err := signer.VerifyToken(tokenString)
+		if !getstream.IsTokenInvalid(err) {
+			t.Error(name, "expected an invalid token error, got:", err)
+		}
+		if getstream.IsTokenExpired(err) {
+			t.Error(name, "expected the token not to be reported as expired")
+		}
+	}
+}
+
+func TestTokenClaimsPermits(t *testing.T) {
+	claims := &getstream.TokenClaims{
+		Context: getstream.ScopeContextFeed,
+		Action:  getstream.ScopeActionRead,
+		FeedID:  "userbob",
+	}
+	all := &getstream.TokenClaims{
+		Context: getstream.ScopeContextAll,
+		Action:  getstream.ScopeActionAll,
+		FeedID:  "*",
+	}
+	user := &getstream.TokenClaims{
+		Context: getstream.ScopeContextFeed,
+		Action:  getstream.ScopeActionRead,
+		UserID:  "bob",
+	}
+
+	for _, test := range []struct {
+		claims   *getstream.TokenClaims
+		context  getstream.ScopeContext
+		action   getstream.ScopeAction
+		feed     string
+		expected bool
+	}{
+		{claims, getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob", true},
+		{claims, getstream.ScopeContextFeed, getstream.ScopeActionRead, "", true},
+		{claims, getstream.ScopeContextFeed, getstream.ScopeActionRead, "useralice", false},
+		{claims, getstream.ScopeContextFeed, getstream.ScopeActionWrite, "userbob", false},
+		{claims, getstream.ScopeContextFollower, getstream.ScopeActionRead, "userbob", false},
+		{all, getstream.ScopeContextActivities, getstream.ScopeActionDelete, "useralice", true},
+		{user, getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob", false},
+	} {
+		if test.claims.Permits(test.context, test.action, test.feed) != test.expected {
+			t.Error(test.claims, test.context.Value(), test.action.Value(), test.feed, "expected", test.expected)
+		}
+	}
+}
+
+func TestSignerTokenPermits(t *testing.T) {
+	signer := getstream.Signer{Secret: "my_secret"}
+
+	tokenString, _ := signer.GenerateFeedScopeToken(getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob")
+	permitted, err := signer.TokenPermits(tokenString, getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob")
+	if err != nil || !permitted {
+		t.Error("expected the token to permit reading the feed, got:", permitted, err)
+	}
+	permitted, err = signer.TokenPermits(tokenString, getstream.ScopeContextFeed, getstream.ScopeActionDelete, "userbob")
+	if err != nil || permitted {
+		t.Error("expected the token not to permit deleting from the feed, got:", permitted, err)
+	}
+
+	_, err = getstream.Signer{Secret: "other_secret"}.TokenPermits(tokenString, getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob")
+	if !getstream.IsTokenInvalid(err) {
+		t.Error("expected an invalid token error, got:", err)
+	}
+}
+
+func TestVerifyTokenSecretRotation(t *testing.T) {
+	oldSigner := getstream.Signer{Secret: "old_secret"}
+	newSigner := getstream.Signer{Secret: "new_secret"}
+	rotating := getstream.Signer{Secret: "new_secret", SecondarySecrets: []string{"older_secret", "old_secret"}}
+
+	oldToken, _ := oldSigner.GenerateFeedScopeToken(getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob")
+	newToken, _ := newSigner.GenerateFeedScopeToken(getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob")
+
+	claims, err := rotating.VerifyToken(newToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.SecretIndex != 0 {
+		t.Error("expected the primary secret to verify the token, got", claims.SecretIndex)
+	}
+
+	claims, err = rotating.VerifyToken(oldToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.SecretIndex != 2 {
+		t.Error("expected the second secondary secret to verify the token, got", claims.SecretIndex)
+	}
+
+	signed, _ := rotating.GenerateFeedScopeToken(getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob")
+	if _, err := newSigner.VerifyToken(signed); err != nil {
+		t.Error("expected tokens to be signed with the primary secret, got:", err)
+	}
+
+	if _, err := newSigner.VerifyToken(oldToken); !getstream.IsTokenInvalid(err) {
+		t.Error("expected the old token to be rejected without the old secret, got:", err)
+	}
+
+	expired, _ := oldSigner.NewToken(getstream.ScopeContextFeed, getstream.ScopeActionRead).ExpiresAt(time.Now().Add(-time.Minute)).Sign()
+	if _, err := rotating.VerifyToken(expired); !getstream.IsTokenExpired(err) {
+		t.Error("expected an expired token signed with a secondary secret to be reported as expired, got:", err)
+	}
+}
+
+func TestVerifyTokenEmptySecrets(t *testing.T) {
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"resource": "*", "action": "*", "feed_id": "*"}).SignedString([]byte(""))
+
+	for name, signer := range map[string]getstream.Signer{
+		"empty secondary secret": {Secret: "my_secret", SecondarySecrets: []string{""}},
+		"empty secret":           {Secret: "", SecondarySecrets: []string{"my_secret"}},
+	} {
+		claims, err := signer.VerifyToken(forged)
+		if !getstream.IsTokenInvalid(err) {
+			t.Error(name, "expected a token signed with an empty key to be rejected, got:", claims, err)
+		}
+		if _, err := signer.VerifyFeedToken("userbob", getstream.Signer{}.GenerateToken("userbob")); !getstream.IsTokenInvalid(err) {
+			t.Error(name, "expected a feed token made with an empty key to be rejected, got:", err)
+		}
+	}
+
+	signer := getstream.Signer{Secret: "new_secret", SecondarySecrets: []string{"", "old_secret"}}
+	oldToken, _ := getstream.Signer{Secret: "old_secret"}.GenerateFeedScopeToken(getstream.ScopeContextFeed, getstream.ScopeActionRead, "userbob")
+	claims, err := signer.VerifyToken(oldToken)
+	if err != nil || claims.SecretIndex != 2 {
+		t.Error("expected the index of the secret in SecondarySecrets to be kept, got:", claims, err)
+	}
+}